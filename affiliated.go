@@ -0,0 +1,160 @@
+package goorgeous
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var reAffiliated = regexp.MustCompile(`(?i)^#\+(CAPTION|NAME|ATTR_[a-z0-9_-]+)(?:\[[^\]]*\])?:`)
+
+// affiliatedEnd returns the index of the first line from lines[i] on that
+// isn't an affiliated keyword.
+func affiliatedEnd(lines [][]byte, i, limit int) int {
+	for i < limit && reAffiliated.Match(lines[i]) {
+		i++
+	}
+	return i
+}
+
+// takesAffiliated reports whether the element starting with data can have
+// affiliated keywords: paragraphs, tables and blocks. Keywords above any
+// other element are kept as keywords.
+func takesAffiliated(data []byte) bool {
+	return isTable(data) || isBlock(data) || !isEmpty(data) && !isHeadline(data) && !startsElement(data)
+}
+
+// parseAffiliated parses the affiliated keyword lines above an element.
+// Several CAPTION lines make up one caption, and several ATTR lines for a
+// backend one set of attributes.
+func (p *parser) parseAffiliated(lines [][]byte) *Affiliated {
+	a := new(Affiliated)
+	var captions []string
+	for _, line := range lines {
+		m := reAffiliated.FindSubmatch(line)
+		value := strings.TrimSpace(string(line[len(m[0]):]))
+		switch key := strings.ToUpper(string(m[1])); key {
+		case "NAME":
+			a.Name = value
+		case "CAPTION":
+			captions = append(captions, value)
+		default:
+			if a.Attributes == nil {
+				a.Attributes = make(map[string]string)
+			}
+			backend := strings.ToLower(key[len("ATTR_"):])
+			if a.Attributes[backend] != "" {
+				value = a.Attributes[backend] + " " + value
+			}
+			a.Attributes[backend] = value
+		}
+	}
+	if len(captions) > 0 {
+		a.Caption = p.inline([]byte(strings.Join(captions, " ")))
+	}
+	return a
+}
+
+// setAffiliated attaches a to n, which takesAffiliated accepted.
+func setAffiliated(n Node, a *Affiliated) {
+	switch n := n.(type) {
+	case *Paragraph:
+		n.Affiliated = a
+	case *Table:
+		n.Affiliated = a
+	case *Block:
+		n.Affiliated = a
+	}
+}
+
+// affiliatedOf returns the affiliated keywords of n, or nil.
+func affiliatedOf(n Node) *Affiliated {
+	switch n := n.(type) {
+	case *Paragraph:
+		return n.Affiliated
+	case *Table:
+		return n.Affiliated
+	case *Block:
+		return n.Affiliated
+	}
+	return nil
+}
+
+// affiliatedText returns the canonical affiliated keyword lines of n,
+// indented like the ones it was parsed with.
+func affiliatedText(n Node, s *source) string {
+	a := affiliatedOf(n)
+	if a == nil {
+		return ""
+	}
+	indent := ""
+	if s != nil {
+		line := firstLine(s.affiliated)
+		indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+
+	var text string
+	if a.Name != "" {
+		text += indent + "#+NAME: " + a.Name + "\n"
+	}
+	if len(a.Caption) > 0 {
+		text += indent + "#+CAPTION: " + orgString(a.Caption) + "\n"
+	}
+	var backends []string
+	for backend := range a.Attributes {
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+	for _, backend := range backends {
+		text += indent + "#+ATTR_" + strings.ToUpper(backend) + ": " + a.Attributes[backend] + "\n"
+	}
+	return text
+}
+
+// htmlAttributes returns the ATTR_HTML attributes of a, with their classes
+// added to the given ones in a single class attribute. Attributes without a
+// value are written bare, or with their name as value in XHTML.
+func htmlAttributes(a *Affiliated, xhtml bool, classes ...string) string {
+	var attrs string
+	if a != nil {
+		for _, pair := range attributePairs(a.Attributes["html"]) {
+			name, value := strings.ToLower(pair[0]), pair[1]
+			switch {
+			case name == "class":
+				classes = append(classes, value)
+			case value == "" && !xhtml:
+				attrs += " " + escapeHTML(name)
+			case value == "":
+				attrs += " " + escapeHTML(name) + "=\"" + escapeHTML(name) + "\""
+			default:
+				attrs += " " + escapeHTML(name) + "=\"" + escapeHTML(value) + "\""
+			}
+		}
+	}
+	if len(classes) > 0 {
+		attrs = " class=\"" + escapeHTML(strings.Join(classes, " ")) + "\"" + attrs
+	}
+	return attrs
+}
+
+// attributePairs splits the value of an ATTR line, such as
+// ":class striped :border 1", into names and values. Values run up to the
+// next name, so they can hold spaces.
+func attributePairs(value string) [][2]string {
+	var pairs [][2]string
+	for _, field := range strings.Fields(value) {
+		if len(field) > 1 && field[0] == ':' {
+			pairs = append(pairs, [2]string{field[1:], ""})
+			continue
+		}
+		if len(pairs) == 0 {
+			continue
+		}
+		last := &pairs[len(pairs)-1]
+		if last[1] != "" {
+			last[1] += " "
+		}
+		last[1] += field
+	}
+	return pairs
+}
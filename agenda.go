@@ -0,0 +1,305 @@
+package goorgeous
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// AgendaOptions configures NewAgenda.
+type AgendaOptions struct {
+	// From is the first day of the agenda and To the day after its last one.
+	// A zero To makes it a one day agenda. Like timestamps, they are taken by
+	// their wall clock dates.
+	From, To time.Time
+
+	// Today is the day overdue items and upcoming deadlines are shown on, if
+	// it is part of the agenda. A zero Today means From.
+	Today time.Time
+
+	// WarningDays is how many days before a deadline it is shown on Today,
+	// unless the deadline has a warning delay like -3d of its own. Zero means
+	// org's default of 14 days.
+	WarningDays int
+}
+
+// AgendaKind is the type of an AgendaEntry.
+type AgendaKind int
+
+// The kinds of agenda entries.
+const (
+	// AgendaScheduled entries come from SCHEDULED timestamps.
+	AgendaScheduled AgendaKind = iota
+
+	// AgendaDeadline entries come from DEADLINE timestamps.
+	AgendaDeadline
+
+	// AgendaEvent entries come from active timestamps in a headline's title
+	// or section.
+	AgendaEvent
+
+	// AgendaTodo entries are headlines with a TODO keyword that isn't a done
+	// state.
+	AgendaTodo
+)
+
+var agendaKindNames = map[AgendaKind]string{
+	AgendaScheduled: "scheduled",
+	AgendaDeadline:  "deadline",
+	AgendaEvent:     "event",
+	AgendaTodo:      "todo",
+}
+
+func (k AgendaKind) String() string {
+	return agendaKindNames[k]
+}
+
+// AgendaEntry is a headline showing up in an agenda. File is the name of
+// the document holding it.
+//
+// Date is the day it shows up on, midnight in UTC, and Timestamp the
+// timestamp that put it there; both are unset for AgendaTodo entries. Days
+// counts the days from Date to a deadline, negative when it has passed, or
+// the days since an item was scheduled.
+type AgendaEntry struct {
+	Kind      AgendaKind
+	Date      time.Time
+	Timestamp *Timestamp
+	Days      int
+	Headline  *Headline
+	File      string
+}
+
+// Agenda is the agenda of a set of documents, like org's agenda view.
+type Agenda struct {
+	// Entries holds the dated entries, ordered by day, then those with a
+	// time of day by time, then by priority.
+	Entries []AgendaEntry
+
+	// Todos holds the headlines with an open TODO keyword in document order,
+	// whether they are dated or not.
+	Todos []AgendaEntry
+}
+
+// NewAgenda builds the agenda of docs, which are keyed by name, usually
+// the path of the file they were parsed from.
+//
+// Repeated timestamps show up on every repetition within the agenda. Items
+// scheduled before Today or with a deadline before Today show up on Today
+// until their headline is done, and so do deadlines within their warning
+// period. Done headlines only show up on the days they are dated.
+func NewAgenda(docs map[string]*Document, opts AgendaOptions) *Agenda {
+	a := &agendaBuilder{AgendaOptions: opts}
+	a.From = day(floating(opts.From))
+	a.To = a.From.AddDate(0, 0, 1)
+	if !opts.To.IsZero() {
+		a.To = day(floating(opts.To))
+	}
+	a.Today = a.From
+	if !opts.Today.IsZero() {
+		a.Today = day(floating(opts.Today))
+	}
+	if a.WarningDays == 0 {
+		a.WarningDays = 14
+	}
+
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a.file = name
+		Walk(docs[name], func(n Node) bool {
+			if h, ok := n.(*Headline); ok {
+				a.headline(h)
+			}
+			return true
+		})
+	}
+
+	sort.SliceStable(a.entries, func(i, j int) bool {
+		return agendaLess(a.entries[i], a.entries[j])
+	})
+	return &Agenda{Entries: a.entries, Todos: a.todos}
+}
+
+// agendaBuilder holds the state of a single NewAgenda call.
+type agendaBuilder struct {
+	AgendaOptions
+	file    string
+	entries []AgendaEntry
+	todos   []AgendaEntry
+}
+
+func (a *agendaBuilder) headline(h *Headline) {
+	open := h.Keyword != "" && !h.Done
+	if open {
+		a.todos = append(a.todos, AgendaEntry{Kind: AgendaTodo, Headline: h, File: a.file})
+	}
+
+	if p := h.Planning; p != nil && p.Scheduled != nil {
+		a.scheduled(h, p.Scheduled, open)
+	}
+	if p := h.Planning; p != nil && p.Deadline != nil {
+		a.deadline(h, p.Deadline, open)
+	}
+
+	for _, ts := range activeTimestamps(h) {
+		a.event(h, ts)
+	}
+}
+
+// activeTimestamps returns the active timestamps in the title and section
+// of h, leaving out its planning line and sub headlines.
+func activeTimestamps(h *Headline) []*Timestamp {
+	nodes := h.Title
+	if len(h.Children) > 0 {
+		if section, ok := h.Children[0].(*Section); ok {
+			nodes = append(append([]Node{}, nodes...), section)
+		}
+	}
+	var timestamps []*Timestamp
+	for _, n := range nodes {
+		Walk(n, func(n Node) bool {
+			if ts, ok := n.(*Timestamp); ok && ts.Active {
+				timestamps = append(timestamps, ts)
+			}
+			_, isClock := n.(*Clock)
+			return !isClock
+		})
+	}
+	return timestamps
+}
+
+func (a *agendaBuilder) scheduled(h *Headline, ts *Timestamp, open bool) {
+	delay := 0
+	if ts.Delay != nil {
+		delay = delayDays(ts.Delay)
+	}
+	onToday := false
+	for _, date := range a.occurrences(ts, delay) {
+		date = date.AddDate(0, 0, delay)
+		onToday = onToday || date.Equal(a.Today)
+		if a.inRange(date) {
+			a.add(AgendaScheduled, date, ts, delay, h)
+		}
+	}
+
+	if since := daysBetween(day(ts.Time), a.Today); open && !onToday && since > delay && a.inRange(a.Today) {
+		a.add(AgendaScheduled, a.Today, ts, since, h)
+	}
+}
+
+func (a *agendaBuilder) deadline(h *Headline, ts *Timestamp, open bool) {
+	onToday := false
+	for _, date := range a.occurrences(ts, 0) {
+		onToday = onToday || date.Equal(a.Today)
+		a.add(AgendaDeadline, date, ts, 0, h)
+	}
+
+	warning := a.WarningDays
+	if ts.Delay != nil {
+		warning = delayDays(ts.Delay)
+	}
+	if until := daysBetween(a.Today, day(ts.Time)); open && !onToday && until <= warning && a.inRange(a.Today) {
+		a.add(AgendaDeadline, a.Today, ts, until, h)
+	}
+}
+
+func (a *agendaBuilder) event(h *Headline, ts *Timestamp) {
+	span := 0
+	if !ts.End.IsZero() {
+		span = daysBetween(day(ts.Time), day(ts.End))
+	}
+	for _, date := range a.occurrences(ts, span) {
+		for i := 0; i <= span; i++ {
+			if d := date.AddDate(0, 0, i); a.inRange(d) {
+				a.add(AgendaEvent, d, ts, 0, h)
+			}
+		}
+	}
+}
+
+// occurrences returns the days on which ts or one of its repetitions
+// occurs, from span days before the agenda to its end. Days with several
+// repetitions, as with hourly repeaters, are returned once.
+func (a *agendaBuilder) occurrences(ts *Timestamp, span int) []time.Time {
+	var dates []time.Time
+	after := a.From.AddDate(0, 0, -span)
+	for {
+		next, ok := ts.Next(after)
+		if !ok || !day(next).Before(a.To) {
+			return dates
+		}
+		dates = append(dates, day(next))
+		if ts.Repeater == nil {
+			return dates
+		}
+		after = day(next).AddDate(0, 0, 1)
+	}
+}
+
+func (a *agendaBuilder) add(kind AgendaKind, date time.Time, ts *Timestamp, days int, h *Headline) {
+	a.entries = append(a.entries, AgendaEntry{Kind: kind, Date: date, Timestamp: ts, Days: days, Headline: h, File: a.file})
+}
+
+func (a *agendaBuilder) inRange(date time.Time) bool {
+	return !date.Before(a.From) && date.Before(a.To)
+}
+
+// agendaLess orders entries by day, then puts those with a time of day
+// first, ordered by time, then orders by priority.
+func agendaLess(a, b AgendaEntry) bool {
+	if !a.Date.Equal(b.Date) {
+		return a.Date.Before(b.Date)
+	}
+	if a.Timestamp.HasTime != b.Timestamp.HasTime {
+		return a.Timestamp.HasTime
+	}
+	if a.Timestamp.HasTime {
+		if at, bt := clockOf(a.Timestamp.Time), clockOf(b.Timestamp.Time); at != bt {
+			return at < bt
+		}
+	}
+	ap, bp := a.Headline.Priority, b.Headline.Priority
+	switch {
+	case ap != nil && bp != nil:
+		return ap.Rank < bp.Rank
+	case ap != nil || bp != nil:
+		return ap != nil
+	}
+	return false
+}
+
+// clockOf returns the time of day of t.
+func clockOf(t time.Time) time.Duration {
+	return t.Sub(day(t))
+}
+
+// day returns the midnight starting the day of t.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween returns the number of days from the day from to the day to,
+// negative when to comes first.
+func daysBetween(from, to time.Time) int {
+	return int(math.Round(float64(to.Unix()-from.Unix()) / (24 * 60 * 60)))
+}
+
+// delayDays returns the length of a delay in days, rounding down hours and
+// counting months as 30 days.
+func delayDays(d *Delay) int {
+	switch d.Unit {
+	case "d":
+		return d.Value
+	case "w":
+		return 7 * d.Value
+	case "m":
+		return 30 * d.Value
+	case "y":
+		return 365 * d.Value
+	}
+	return d.Value / 24
+}
@@ -0,0 +1,113 @@
+package goorgeous
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const workOrg = `* TODO [#B] Write report
+SCHEDULED: <2024-01-01 Mon>
+* TODO [#A] Pay rent
+DEADLINE: <2024-01-05 Fri>
+* TODO Renew passport
+DEADLINE: <2024-01-20 Sat -3d>
+* DONE Old report
+SCHEDULED: <2024-01-02 Tue> DEADLINE: <2024-01-04 Thu>
+* Standup
+<2024-01-01 Mon 9:00 +1w>
+* Conference
+<2024-01-03 Wed>--<2024-01-04 Thu>
+* TODO Water plants
+SCHEDULED: <2024-01-06 Sat -1d>
+`
+
+const homeOrg = `* Dentist <2024-01-03 Wed 14:00>
+* WAIT Someday
+** Call
+CLOCK: [2024-01-03 Wed 10:00]--[2024-01-03 Wed 11:00] =>  1:00
+`
+
+func TestAgenda(t *testing.T) {
+	work, _ := Parse([]byte(workOrg))
+	home, _ := ParseWithOptions([]byte(homeOrg), ParseOptions{TodoKeywords: []string{"WAIT | DONE"}})
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	a := NewAgenda(map[string]*Document{"work.org": work, "home.org": home}, AgendaOptions{
+		From:        day(3),
+		To:          day(10),
+		Today:       day(3),
+		WarningDays: 3,
+	})
+	var entries []string
+	for _, e := range a.Entries {
+		entries = append(entries, fmt.Sprintf("%d %s %s %d", e.Date.Day(), e.Kind, plainText(e.Headline.Title), e.Days))
+	}
+	expected := []string{
+		"3 event Dentist <2024-01-03 Wed 14:00> 0",
+		"3 deadline Pay rent 2",
+		"3 scheduled Write report 2",
+		"3 event Conference 0",
+		"4 deadline Old report 0",
+		"4 event Conference 0",
+		"5 deadline Pay rent 0",
+		"7 scheduled Water plants 1",
+		"8 event Standup 0",
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Entries = %q\nwants: %q", entries, expected)
+	}
+
+	var todos []string
+	for _, e := range a.Todos {
+		todos = append(todos, e.File+": "+plainText(e.Headline.Title))
+	}
+	expectedTodos := []string{"home.org: Someday", "work.org: Write report", "work.org: Pay rent", "work.org: Renew passport", "work.org: Water plants"}
+	if !reflect.DeepEqual(todos, expectedTodos) {
+		t.Errorf("Todos = %q\nwants: %q", todos, expectedTodos)
+	}
+}
+
+func TestAgendaHourlyRepeater(t *testing.T) {
+	doc, _ := Parse([]byte("* Check mail\n<2024-01-03 Wed 9:00 +1h>\n* TODO Stretch\nSCHEDULED: <2024-01-04 Thu 22:00 +3h>\n* Ancient <1700-01-01 Fri 10:00 +1h>\n"))
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	a := NewAgenda(map[string]*Document{"a.org": doc}, AgendaOptions{From: day(3), To: day(6), Today: day(3)})
+	var entries []string
+	for _, e := range a.Entries {
+		entries = append(entries, fmt.Sprintf("%d %s %s", e.Date.Day(), e.Kind, plainText(e.Headline.Title)))
+	}
+	expected := []string{
+		"3 event Check mail",
+		"3 event Ancient <1700-01-01 Fri 10:00 +1h>",
+		"4 event Check mail",
+		"4 event Ancient <1700-01-01 Fri 10:00 +1h>",
+		"4 scheduled Stretch",
+		"5 event Check mail",
+		"5 event Ancient <1700-01-01 Fri 10:00 +1h>",
+		"5 scheduled Stretch",
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Entries = %q\nwants: %q", entries, expected)
+	}
+}
+
+func TestAgendaOverdueDeadlines(t *testing.T) {
+	doc, _ := Parse([]byte("* TODO Today\nDEADLINE: <2024-01-03 Wed>\n* TODO Yesterday\nDEADLINE: <2024-01-02 Tue>\n* TODO Two days ago\nDEADLINE: <2024-01-01 Mon>\n* TODO Tomorrow\nDEADLINE: <2024-01-04 Thu>\n"))
+	today := time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC)
+
+	a := NewAgenda(map[string]*Document{"a.org": doc}, AgendaOptions{From: today, Today: today, WarningDays: 1})
+	days := make(map[string]int)
+	for _, e := range a.Entries {
+		days[plainText(e.Headline.Title)] = e.Days
+	}
+	expected := map[string]int{"Today": 0, "Yesterday": -1, "Two days ago": -2, "Tomorrow": 1}
+	if !reflect.DeepEqual(days, expected) {
+		t.Errorf("Days = %v\nwants: %v", days, expected)
+	}
+}
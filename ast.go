@@ -0,0 +1,370 @@
+package goorgeous
+
+import "time"
+
+// Node is a single element of a parsed org document. Every type in this file
+// implements Node; Parse returns them arranged in a tree rooted at *Document.
+type Node interface {
+	node()
+}
+
+// Document is the root of a parsed org document. Its children are an optional
+// leading *Section followed by the top level *Headline nodes.
+type Document struct {
+	Children []Node
+
+	// src holds the text each node was parsed from, see Write
+	src map[Node]*source
+}
+
+// Headline is an org headline. Its children are an optional *Section holding
+// the headline's content followed by any sub headlines. Keyword holds the
+// headline's TODO keyword and Done whether that keyword is a done state.
+// Priority is nil unless the headline has a priority cookie such as [#A], and
+// Planning unless the line after it is a planning line. Properties holds the
+// property drawer that follows them, see Property.
+type Headline struct {
+	Level      int
+	Keyword    string
+	Done       bool
+	Priority   *Priority
+	Title      []Node
+	Tags       []string
+	Planning   *Planning
+	Properties map[string]string
+	Children   []Node
+}
+
+// Priority is the priority cookie of a headline. Value is the letter or
+// number inside the cookie and Rank its distance from the document's highest
+// priority, so headlines sort by urgency on Rank: 0 is the most urgent.
+type Priority struct {
+	Value string `json:"value"`
+	Rank  int    `json:"rank"`
+}
+
+// Planning is the SCHEDULED, DEADLINE and CLOSED line directly under a
+// headline. Timestamps the line doesn't hold are nil.
+type Planning struct {
+	Scheduled *Timestamp
+	Deadline  *Timestamp
+	Closed    *Timestamp
+}
+
+// Section holds the elements between two headlines.
+type Section struct {
+	Children []Node
+}
+
+// Paragraph is a run of text lines. Its children are inline nodes.
+type Paragraph struct {
+	Affiliated *Affiliated
+	Children   []Node
+}
+
+// Affiliated holds the affiliated keywords of a paragraph, table or block:
+// the #+NAME, #+CAPTION and #+ATTR_BACKEND lines right above it. Caption
+// holds inline nodes, and Attributes the value of the ATTR lines keyed by
+// their lower case backend, e.g. "html" for ":class striped :border 1".
+type Affiliated struct {
+	Name       string
+	Caption    []Node
+	Attributes map[string]string
+}
+
+// ListKind is the type of a List.
+type ListKind int
+
+// The kinds of lists org supports.
+const (
+	UnorderedList ListKind = iota
+	OrderedList
+	DescriptiveList
+)
+
+// List is a plain list made up of consecutive items.
+type List struct {
+	Kind  ListKind
+	Items []*ListItem
+}
+
+// ListItem is a single item of a List. Bullet holds the item's bullet as
+// written ("-", "+" or "1.") and Counter the value of a [@N] counter cookie.
+// Checkbox holds the state of the item's checkbox: " " for [ ], "X" for
+// [X] and "-" for [-], or nothing when it has none. Term is only set for
+// items of descriptive lists. Children starts with the paragraph on the
+// bullet line, followed by the elements indented under it, such as further
+// paragraphs, blocks, tables and sublists.
+type ListItem struct {
+	Bullet   string
+	Counter  string
+	Checkbox string
+	Term     []Node
+	Children []Node
+}
+
+// Table is an org table. Rows holds every row as written, rules and cookie
+// rows included; its Header and Bodies methods group the rows the way they are
+// exported.
+type Table struct {
+	Affiliated *Affiliated
+	Rows       []*TableRow
+
+	// Formulas holds the values of the #+TBLFM lines below the table, such
+	// as "$4=$2*$3::@>$4=vsum(@2..@-1)". Recalculate evaluates them.
+	Formulas []string
+}
+
+// TableRow is a row of a Table. Rule rows (|---+---|) have no cells.
+type TableRow struct {
+	Rule  bool
+	Cells []*TableCell
+}
+
+// TableCell is a single cell of a TableRow. Its children are inline nodes.
+type TableCell struct {
+	Children []Node
+}
+
+// Block is a #+BEGIN_NAME ... #+END_NAME block. QUOTE and CENTER blocks hold
+// parsed elements in Children, every other block keeps its contents verbatim
+// in Lines.
+type Block struct {
+	Affiliated *Affiliated
+	Name       string
+	Parameters []string
+	Lines      []string
+	Children   []Node
+}
+
+// Drawer is a :NAME: ... :END: drawer. PROPERTIES drawers keep their
+// contents verbatim in Lines, every other drawer holds parsed elements in
+// Children.
+type Drawer struct {
+	Name     string
+	Lines    []string
+	Children []Node
+}
+
+// Clock is a CLOCK: line recording time spent on a task. Timestamp is an
+// inactive timestamp whose End is zero while the clock is still running.
+// Duration is the time written after =>, or the time between the start and
+// end of Timestamp if there is none.
+type Clock struct {
+	Timestamp *Timestamp
+	Duration  time.Duration
+}
+
+// FootnoteDefinition is a [fn:label] definition.
+type FootnoteDefinition struct {
+	Label    string
+	Children []Node
+}
+
+// FixedWidth is a run of lines starting with a colon.
+type FixedWidth struct {
+	Lines []string
+}
+
+// Keyword is a #+KEY: value line.
+type Keyword struct {
+	Key   string
+	Value string
+}
+
+// Comment is a line starting with "# ".
+type Comment struct {
+	Value string
+}
+
+// HorizontalRule is a line of five or more dashes.
+type HorizontalRule struct{}
+
+// Text is plain text.
+type Text struct {
+	Value string
+}
+
+// EmphasisKind is the type of an Emphasis.
+type EmphasisKind int
+
+// The kinds of emphasis org supports.
+const (
+	Bold EmphasisKind = iota
+	Italic
+	Underline
+	StrikeThrough
+)
+
+// Emphasis is text wrapped in *bold*, /italic/, _underline_ or +strike+
+// markers. Its children are inline nodes.
+type Emphasis struct {
+	Kind     EmphasisKind
+	Children []Node
+}
+
+// Code is =verbatim= or ~code~ text.
+type Code struct {
+	Verbatim bool
+	Value    string
+}
+
+// Link is a [[url]] or [[url][description]] link. Description holds inline
+// nodes and is empty when the link has no description.
+type Link struct {
+	URL         string
+	Description []Node
+}
+
+// FootnoteReference is a [fn:label] reference.
+type FootnoteReference struct {
+	Label string
+}
+
+// StatisticsCookie is a [2/5] or [40%] cookie counting the done TODO
+// headlines or checked items below it. Value is the text between the
+// brackets; UpdateStatistics recomputes it.
+type StatisticsCookie struct {
+	Value string
+}
+
+// Timestamp is an org timestamp such as <2024-01-02 Tue> or
+// [2024-01-02 Tue 10:30]. Active timestamps are written in angle brackets.
+// Org timestamps have no time zone, so Time holds the date and time as
+// written, in UTC. HasTime reports whether a time of day was given.
+//
+// End is zero unless the timestamp is a range, either <a>--<b> or a time
+// range such as <2024-01-02 Tue 10:00-12:00>.
+type Timestamp struct {
+	Active   bool
+	Time     time.Time
+	HasTime  bool
+	End      time.Time
+	Repeater *Repeater
+	Delay    *Delay
+}
+
+// Repeater is the repeater of a timestamp, such as +1w. Mark is "+", "++"
+// or ".+", Unit is one of "h", "d", "w", "m" and "y".
+type Repeater struct {
+	Mark  string `json:"mark"`
+	Value int    `json:"value"`
+	Unit  string `json:"unit"`
+}
+
+// Delay is the warning delay of a timestamp, such as -3d. FirstOnly is set
+// for delays like --3d, which only apply to the first repetition.
+type Delay struct {
+	FirstOnly bool   `json:"firstOnly,omitempty"`
+	Value     int    `json:"value"`
+	Unit      string `json:"unit"`
+}
+
+func (*Document) node()           {}
+func (*Headline) node()           {}
+func (*Section) node()            {}
+func (*Paragraph) node()          {}
+func (*List) node()               {}
+func (*ListItem) node()           {}
+func (*Table) node()              {}
+func (*TableRow) node()           {}
+func (*TableCell) node()          {}
+func (*Block) node()              {}
+func (*Drawer) node()             {}
+func (*FootnoteDefinition) node() {}
+func (*FixedWidth) node()         {}
+func (*Keyword) node()            {}
+func (*Comment) node()            {}
+func (*HorizontalRule) node()     {}
+func (*Text) node()               {}
+func (*Emphasis) node()           {}
+func (*Code) node()               {}
+func (*Link) node()               {}
+func (*FootnoteReference) node()  {}
+func (*StatisticsCookie) node()   {}
+func (*Planning) node()           {}
+func (*Affiliated) node()         {}
+func (*Clock) node()              {}
+func (*Timestamp) node()          {}
+
+// Walk traverses the tree rooted at n depth first, calling fn for every node.
+// If fn returns false the children of that node are skipped.
+func Walk(n Node, fn func(Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range childNodes(n) {
+		Walk(child, fn)
+	}
+}
+
+func childNodes(n Node) []Node {
+	switch n := n.(type) {
+	case *Document:
+		return n.Children
+	case *Headline:
+		children := append([]Node{}, n.Title...)
+		if n.Planning != nil {
+			children = append(children, n.Planning)
+		}
+		return append(children, n.Children...)
+	case *Planning:
+		var children []Node
+		for _, ts := range []*Timestamp{n.Scheduled, n.Deadline, n.Closed} {
+			if ts != nil {
+				children = append(children, ts)
+			}
+		}
+		return children
+	case *Section:
+		return n.Children
+	case *Paragraph:
+		return withAffiliated(n.Affiliated, n.Children)
+	case *Affiliated:
+		return n.Caption
+	case *List:
+		children := make([]Node, len(n.Items))
+		for i, item := range n.Items {
+			children[i] = item
+		}
+		return children
+	case *ListItem:
+		return append(append([]Node{}, n.Term...), n.Children...)
+	case *Table:
+		children := make([]Node, len(n.Rows))
+		for i, row := range n.Rows {
+			children[i] = row
+		}
+		return withAffiliated(n.Affiliated, children)
+	case *TableRow:
+		children := make([]Node, len(n.Cells))
+		for i, cell := range n.Cells {
+			children[i] = cell
+		}
+		return children
+	case *TableCell:
+		return n.Children
+	case *Block:
+		return withAffiliated(n.Affiliated, n.Children)
+	case *Drawer:
+		return n.Children
+	case *Clock:
+		return []Node{n.Timestamp}
+	case *FootnoteDefinition:
+		return n.Children
+	case *Emphasis:
+		return n.Children
+	case *Link:
+		return n.Description
+	}
+	return nil
+}
+
+// withAffiliated puts the affiliated keywords of an element, if it has any,
+// in front of its children.
+func withAffiliated(a *Affiliated, children []Node) []Node {
+	if a == nil {
+		return children
+	}
+	return append([]Node{a}, children...)
+}
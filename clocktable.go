@@ -0,0 +1,216 @@
+package goorgeous
+
+import (
+	"io"
+	"sort"
+	"time"
+)
+
+// ClockReportOptions configures NewClockReport.
+type ClockReportOptions struct {
+	// From and To limit the report to the time clocked from From up to To;
+	// clocks crossing them are cut. A zero From or To leaves that side open.
+	// Like timestamps, they are compared by their wall clock time.
+	From, To time.Time
+}
+
+// ClockReport is the time clocked in a document, summed up the way org's
+// clocktable does: per headline, including the time of sub headlines, per
+// tag and per day.
+//
+// Times are computed from the start and end of closed CLOCK lines; running
+// clocks aren't counted.
+type ClockReport struct {
+	Total     time.Duration
+	Headlines []*ClockEntry
+
+	// Tags holds the time clocked under each tag, counting the time of
+	// headlines for their own tags and the tags they inherit.
+	Tags map[string]time.Duration
+
+	// Days holds the time clocked on each day, in order.
+	Days []ClockDay
+}
+
+// ClockEntry is the time clocked for a headline. Time is the time of its
+// own clocks and Total includes that of its sub headlines, which are the
+// Children with any time clocked.
+type ClockEntry struct {
+	Headline *Headline
+	Time     time.Duration
+	Total    time.Duration
+	Children []*ClockEntry
+}
+
+// ClockDay is the time clocked on a day. Date is the day's midnight in UTC.
+type ClockDay struct {
+	Date  time.Time
+	Total time.Duration
+}
+
+// NewClockReport sums up the clocks of doc.
+func NewClockReport(doc *Document, opts ClockReportOptions) *ClockReport {
+	c := &clockCounter{days: make(map[time.Time]time.Duration)}
+	if !opts.From.IsZero() {
+		c.from = floating(opts.From)
+	}
+	if !opts.To.IsZero() {
+		c.to = floating(opts.To)
+	}
+
+	r := &ClockReport{Tags: make(map[string]time.Duration)}
+	c.tags = r.Tags
+	r.Headlines, r.Total = c.entries(doc.Children, nil)
+	for date, total := range c.days {
+		r.Days = append(r.Days, ClockDay{Date: date, Total: total})
+	}
+	sort.Slice(r.Days, func(i, j int) bool { return r.Days[i].Date.Before(r.Days[j].Date) })
+	return r
+}
+
+// clockCounter holds the state of a single NewClockReport call.
+type clockCounter struct {
+	from, to time.Time
+	tags     map[string]time.Duration
+	days     map[time.Time]time.Duration
+}
+
+func (c *clockCounter) entries(nodes []Node, inherited []string) ([]*ClockEntry, time.Duration) {
+	var entries []*ClockEntry
+	var total time.Duration
+	for _, n := range nodes {
+		h, ok := n.(*Headline)
+		if !ok {
+			continue
+		}
+		tags := appendTags(inherited, h.Tags)
+
+		entry := &ClockEntry{Headline: h}
+		for _, clock := range h.Clocks() {
+			entry.Time += c.count(clock)
+		}
+		for _, tag := range tags {
+			if entry.Time > 0 {
+				c.tags[tag] += entry.Time
+			}
+		}
+
+		var children time.Duration
+		entry.Children, children = c.entries(h.Children, tags)
+		entry.Total = entry.Time + children
+		if entry.Total > 0 {
+			entries = append(entries, entry)
+			total += entry.Total
+		}
+	}
+	return entries, total
+}
+
+// count returns the time of clock within the report's range, adding it to
+// the days it falls on.
+func (c *clockCounter) count(clock *Clock) time.Duration {
+	start, end := clock.Timestamp.Time, clock.Timestamp.End
+	if end.IsZero() {
+		return 0
+	}
+	if !c.from.IsZero() && start.Before(c.from) {
+		start = c.from
+	}
+	if !c.to.IsZero() && end.After(c.to) {
+		end = c.to
+	}
+	if !end.After(start) {
+		return 0
+	}
+
+	var total time.Duration
+	for day := start.Truncate(24 * time.Hour); day.Before(end); day = day.AddDate(0, 0, 1) {
+		from, to := start, end
+		if from.Before(day) {
+			from = day
+		}
+		if next := day.AddDate(0, 0, 1); to.After(next) {
+			to = next
+		}
+		c.days[day] += to.Sub(from)
+		total += to.Sub(from)
+	}
+	return total
+}
+
+// appendTags returns the tags of inherited followed by those of tags it
+// doesn't hold yet.
+func appendTags(inherited, tags []string) []string {
+	all := append([]string{}, inherited...)
+	for _, tag := range tags {
+		found := false
+		for _, t := range all {
+			found = found || t == tag
+		}
+		if !found {
+			all = append(all, tag)
+		}
+	}
+	return all
+}
+
+// Table returns the headline totals of the report as a table in org's
+// clocktable layout: a row per headline, with the time of every level of
+// headlines in a column of its own, below a row with the total time.
+func (r *ClockReport) Table() *Table {
+	columns := 2
+	if depth := clockDepth(r.Headlines); depth > 1 {
+		columns = 1 + depth
+	}
+	row := func(title []Node, level int, time []Node) *TableRow {
+		cells := make([]*TableCell, columns)
+		for i := range cells {
+			cells[i] = &TableCell{}
+		}
+		cells[0].Children, cells[level].Children = title, time
+		return &TableRow{Cells: cells}
+	}
+	bold := func(text string) []Node {
+		return []Node{&Emphasis{Kind: Bold, Children: []Node{&Text{Value: text}}}}
+	}
+
+	t := &Table{Rows: []*TableRow{
+		row([]Node{&Text{Value: "Headline"}}, 1, []Node{&Text{Value: "Time"}}),
+		{Rule: true},
+		row(bold("Total time"), 1, bold(clockDuration(r.Total))),
+		{Rule: true},
+	}}
+	var add func(entries []*ClockEntry, level int)
+	add = func(entries []*ClockEntry, level int) {
+		for _, entry := range entries {
+			t.Rows = append(t.Rows, row(entry.Headline.Title, level, []Node{&Text{Value: clockDuration(entry.Total)}}))
+			add(entry.Children, level+1)
+		}
+	}
+	add(r.Headlines, 1)
+	return t
+}
+
+func clockDepth(entries []*ClockEntry) int {
+	depth := 0
+	for _, entry := range entries {
+		if d := 1 + clockDepth(entry.Children); d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// WriteOrg writes the table of the report to w as org content.
+func (r *ClockReport) WriteOrg(w io.Writer) error {
+	return Write(w, r.document())
+}
+
+// WriteHTML writes the table of the report to w as HTML.
+func (r *ClockReport) WriteHTML(w io.Writer, opts HTMLOptions) error {
+	return NewHTMLRenderer(opts).Render(w, r.document())
+}
+
+func (r *ClockReport) document() *Document {
+	return &Document{Children: []Node{&Section{Children: []Node{r.Table()}}}}
+}
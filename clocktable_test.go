@@ -0,0 +1,73 @@
+package goorgeous
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const clockedOrg = `* Client A :acme:
+:LOGBOOK:
+CLOCK: [2024-01-02 Tue 23:00]--[2024-01-03 Wed 01:00] =>  2:00
+:END:
+** Design :design:
+:LOGBOOK:
+CLOCK: [2024-01-03 Wed 09:00]--[2024-01-03 Wed 10:30] =>  1:30
+CLOCK: [2024-01-04 Thu 09:00]
+:END:
+** Meetings
+* Client B
+CLOCK: [2024-01-05 Fri 14:00]--[2024-01-05 Fri 14:45] =>  0:45
+* Unclocked
+`
+
+func TestClockReport(t *testing.T) {
+	doc, _ := Parse([]byte(clockedOrg))
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	r := NewClockReport(doc, ClockReportOptions{})
+	if r.Total != 255*time.Minute {
+		t.Errorf("Total = %s\nwants: %s", r.Total, 255*time.Minute)
+	}
+	expectedTags := map[string]time.Duration{"acme": 210 * time.Minute, "design": 90 * time.Minute}
+	if !reflect.DeepEqual(r.Tags, expectedTags) {
+		t.Errorf("Tags = %v\nwants: %v", r.Tags, expectedTags)
+	}
+	expectedDays := []ClockDay{{day(2), time.Hour}, {day(3), 150 * time.Minute}, {day(5), 45 * time.Minute}}
+	if !reflect.DeepEqual(r.Days, expectedDays) {
+		t.Errorf("Days = %v\nwants: %v", r.Days, expectedDays)
+	}
+
+	var out bytes.Buffer
+	r.WriteOrg(&out)
+	expected := "| Headline | Time |  |\n|---+---+---|\n| *Total time* | *4:15* |  |\n|---+---+---|\n| Client A | 3:30 |  |\n| Design |  | 1:30 |\n| Client B | 0:45 |  |\n"
+	if out.String() != expected {
+		t.Errorf("WriteOrg() = %q\nwants: %q", out.String(), expected)
+	}
+
+	r = NewClockReport(doc, ClockReportOptions{From: day(3), To: time.Date(2024, 1, 3, 10, 0, 0, 0, time.FixedZone("", 3600))})
+	out.Reset()
+	r.WriteHTML(&out, HTMLOptions{})
+	expected = "<table>\n<thead>\n<tr>\n<th>Headline</th>\n<th>Time</th>\n<th class=\"align-right\"></th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td><strong>Total time</strong></td>\n<td><strong>2:00</strong></td>\n<td class=\"align-right\"></td>\n</tr>\n</tbody>\n<tbody>\n<tr>\n<td>Client A</td>\n<td>2:00</td>\n<td class=\"align-right\"></td>\n</tr>\n<tr>\n<td>Design</td>\n<td></td>\n<td class=\"align-right\">1:00</td>\n</tr>\n</tbody>\n</table>\n"
+	if out.String() != expected {
+		t.Errorf("WriteHTML() = %q\nwants: %q", out.String(), expected)
+	}
+
+	// the clocks of Client A and Client B lie outside the range on its first
+	// and last day
+	r = NewClockReport(doc, ClockReportOptions{From: day(3).Add(9*time.Hour + 30*time.Minute), To: day(5).Add(14 * time.Hour)})
+	if r.Total != time.Hour {
+		t.Errorf("Total = %s\nwants: %s", r.Total, time.Hour)
+	}
+	expectedTags = map[string]time.Duration{"acme": time.Hour, "design": time.Hour}
+	if !reflect.DeepEqual(r.Tags, expectedTags) {
+		t.Errorf("Tags = %v\nwants: %v", r.Tags, expectedTags)
+	}
+	expectedDays = []ClockDay{{day(3), time.Hour}}
+	if !reflect.DeepEqual(r.Days, expectedDays) {
+		t.Errorf("Days = %v\nwants: %v", r.Days, expectedDays)
+	}
+}
@@ -0,0 +1,81 @@
+package goorgeous
+
+import (
+	"strconv"
+	"strings"
+)
+
+// standaloneImage returns the image link p consists of, or nil if p holds
+// anything else. Org exports such paragraphs as figures.
+func standaloneImage(p *Paragraph) *Link {
+	var img *Link
+	for _, n := range p.Children {
+		switch n := n.(type) {
+		case *Link:
+			if _, isImage := linkTarget(n); !isImage || img != nil {
+				return nil
+			}
+			img = n
+		case *Text:
+			if strings.TrimSpace(n.Value) != "" {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	return img
+}
+
+// figures numbers the figures of a document, the standalone images with a
+// caption, and finds the elements its links refer to by #+NAME.
+type figures struct {
+	numbers map[*Affiliated]int
+	names   map[string]Node
+}
+
+func newFigures(doc *Document) *figures {
+	f := &figures{numbers: make(map[*Affiliated]int), names: make(map[string]Node)}
+	Walk(doc, func(n Node) bool {
+		a := affiliatedOf(n)
+		if a == nil {
+			return true
+		}
+		if _, seen := f.names[a.Name]; a.Name != "" && !seen {
+			f.names[a.Name] = n
+		}
+		if p, ok := n.(*Paragraph); ok && len(a.Caption) > 0 && standaloneImage(p) != nil {
+			f.numbers[a] = len(f.numbers) + 1
+		}
+		return true
+	})
+	return f
+}
+
+// number returns the number of the figure with the affiliated keywords a, or
+// zero if it isn't one.
+func (f *figures) number(a *Affiliated) int {
+	if f == nil {
+		return 0
+	}
+	return f.numbers[a]
+}
+
+// target returns the element l refers to by name, or nil if it refers to
+// something else.
+func (f *figures) target(l *Link) Node {
+	if f == nil {
+		return nil
+	}
+	return f.names[l.URL]
+}
+
+// label returns the text of a link without a description to the named
+// element n: its figure number, or its name when it isn't a figure.
+func (f *figures) label(n Node) string {
+	a := affiliatedOf(n)
+	if number := f.number(a); number > 0 {
+		return strconv.Itoa(number)
+	}
+	return a.Name
+}
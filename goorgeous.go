@@ -1,326 +1,110 @@
 package goorgeous
 
 import (
-	"bufio"
 	"bytes"
 	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/russross/blackfriday"
 	"github.com/shurcooL/sanitized_anchor_name"
 )
 
-type inlineParser func(p *parser, out *bytes.Buffer, data []byte, offset int) int
+type inlineParser func(p *parser, data []byte, offset int) (Node, int)
 
 type footnotes struct {
-	id  string
-	def string
+	id string
 }
 
 type parser struct {
 	r              blackfriday.Renderer
 	inlineCallback [256]inlineParser
 	notes          []footnotes
+	defs           map[string]*FootnoteDefinition
+	figures        *figures
+
+	// todo maps the TODO keywords of the document being parsed to whether
+	// they are done states
+	todo map[string]bool
+	// priorities is the priority range of the document being parsed
+	priorities priorityRange
+
+	// used while parsing to record where nodes came from; lineOffset is the
+	// index of the first line of the list item body being parsed, which
+	// line indices are relative to
+	input      []byte
+	lineStart  []int
+	lineOffset int
+	src        map[Node]*source
 }
 
 // NewParser returns a new parser with the inlineCallbacks required for org content
+//
+// Deprecated: use Parse to get a Document and a Renderer such as HTMLRenderer to render it.
 func NewParser(renderer blackfriday.Renderer) *parser {
 	p := new(parser)
 	p.r = renderer
 
-	p.inlineCallback['='] = generateVerbatim
-	p.inlineCallback['~'] = generateCode
-	p.inlineCallback['/'] = generateEmphasis
-	p.inlineCallback['_'] = generateUnderline
-	p.inlineCallback['*'] = generateBold
-	p.inlineCallback['+'] = generateStrikethrough
-	p.inlineCallback['['] = generateLinkOrImg
+	p.inlineCallback['='] = parseVerbatim
+	p.inlineCallback['~'] = parseCode
+	p.inlineCallback['/'] = parseEmphasis(Italic)
+	p.inlineCallback['_'] = parseEmphasis(Underline)
+	p.inlineCallback['*'] = parseEmphasis(Bold)
+	p.inlineCallback['+'] = parseEmphasis(StrikeThrough)
+	p.inlineCallback['['] = parseLinkOrImg
+	p.inlineCallback['<'] = parseInlineTimestamp
 
 	return p
 }
 
 // OrgCommon is the easiest way to parse a byte slice of org content and makes assumptions
 // that the caller wants to use blackfriday's HTMLRenderer with XHTML
+//
+// Deprecated: use HTML with HTMLOptions{XHTML: true} instead.
 func OrgCommon(input []byte) []byte {
 	renderer := blackfriday.HtmlRenderer(blackfriday.HTML_USE_XHTML, "", "")
 	return OrgOptions(input, renderer)
 }
 
 // Org is a convenience name for OrgOptions
+//
+// Deprecated: use HTML or an HTMLRenderer instead.
 func Org(input []byte, renderer blackfriday.Renderer) []byte {
 	return OrgOptions(input, renderer)
 }
 
-// OrgOptions takes an org content byte slice and a renderer to use
+// OrgOptions takes an org content byte slice and a renderer to use.
+//
+// Deprecated: OrgOptions depends on the blackfriday v1 Renderer interface. Use
+// HTML or an HTMLRenderer instead.
 func OrgOptions(input []byte, renderer blackfriday.Renderer) []byte {
-	// in the case that we need to render something in isEmpty but there isn't a new line char
-	input = append(input, '\n')
-	var output bytes.Buffer
-
-	p := NewParser(renderer)
-
-	scanner := bufio.NewScanner(bytes.NewReader(input))
-	// used to capture code blocks
-	marker := ""
-	syntax := ""
-	listType := ""
-	inParagraph := false
-	inList := false
-	inTable := false
-	inFixedWidthArea := false
-	inFootNote := false
-	curFootNoteId := ""
-	var tmpBlock bytes.Buffer
-
-	for scanner.Scan() {
-		data := scanner.Bytes()
-
-		if !isEmpty(data) && isComment(data) || IsKeyword(data) {
-			switch {
-			case inList:
-				if tmpBlock.Len() > 0 {
-					p.generateList(&output, tmpBlock.Bytes(), listType)
-				}
-				inList = false
-				listType = ""
-				tmpBlock.Reset()
-			case inTable:
-				if tmpBlock.Len() > 0 {
-					p.generateTable(&output, tmpBlock.Bytes())
-				}
-				inTable = false
-				tmpBlock.Reset()
-			case inParagraph:
-				if tmpBlock.Len() > 0 {
-					p.generateParagraph(&output, tmpBlock.Bytes()[:len(tmpBlock.Bytes())-1])
-				}
-				inParagraph = false
-				tmpBlock.Reset()
-			case inFixedWidthArea:
-				if tmpBlock.Len() > 0 {
-					tmpBlock.WriteString("</pre>\n")
-					output.Write(tmpBlock.Bytes())
-				}
-				inFixedWidthArea = false
-				tmpBlock.Reset()
-			case inFootNote:
-				inFootNote = false
-				curFootNoteId = ""
-			}
-
-		}
+	return OrgWithParseOptions(input, renderer, ParseOptions{})
+}
 
-		switch {
-		case isEmpty(data):
-			switch {
-			case inList:
-				if tmpBlock.Len() > 0 {
-					p.generateList(&output, tmpBlock.Bytes(), listType)
-				}
-				inList = false
-				listType = ""
-				tmpBlock.Reset()
-			case inTable:
-				if tmpBlock.Len() > 0 {
-					p.generateTable(&output, tmpBlock.Bytes())
-				}
-				inTable = false
-				tmpBlock.Reset()
-			case inParagraph:
-				if tmpBlock.Len() > 0 {
-					p.generateParagraph(&output, tmpBlock.Bytes()[:len(tmpBlock.Bytes())-1])
-				}
-				inParagraph = false
-				tmpBlock.Reset()
-			case inFixedWidthArea:
-				if tmpBlock.Len() > 0 {
-					tmpBlock.WriteString("</pre>\n")
-					output.Write(tmpBlock.Bytes())
-				}
-				inFixedWidthArea = false
-				tmpBlock.Reset()
-			case inFootNote:
-				inFootNote = false
-				curFootNoteId = ""
-			case marker != "":
-				tmpBlock.WriteByte('\n')
-			default:
-				continue
-			}
-		case isPropertyDrawer(data) || marker == "PROPERTIES":
-			if marker == "" {
-				marker = "PROPERTIES"
-			}
-			if bytes.Equal(data, []byte(":END:")) {
-				marker = ""
-			}
-			continue
-		case isBlock(data) || marker != "":
-			matches := reBlock.FindSubmatch(data)
-			if len(matches) > 0 {
-				if string(matches[1]) == "END" {
-					switch marker {
-					case "QUOTE":
-						var tmpBuf bytes.Buffer
-						p.inline(&tmpBuf, tmpBlock.Bytes())
-						p.r.BlockQuote(&output, tmpBuf.Bytes())
-					case "CENTER":
-						var tmpBuf bytes.Buffer
-						output.WriteString("<center>\n")
-						p.inline(&tmpBuf, tmpBlock.Bytes())
-						output.Write(tmpBuf.Bytes())
-						output.WriteString("</center>\n")
-					default:
-						tmpBlock.WriteByte('\n')
-						p.r.BlockCode(&output, tmpBlock.Bytes(), syntax)
-					}
-					marker = ""
-					tmpBlock.Reset()
-					continue
-				}
+// OrgWithParseOptions is OrgOptions with options for parsing the input, such
+// as recalculating tables with #+TBLFM formulas and computing statistics
+// cookies instead of rendering the values stored in the input.
+//
+// Deprecated: OrgWithParseOptions depends on the blackfriday v1 Renderer
+// interface. Use ParseWithOptions and an HTMLRenderer instead.
+func OrgWithParseOptions(input []byte, renderer blackfriday.Renderer, opts ParseOptions) []byte {
+	var output bytes.Buffer
 
-			}
-			if marker != "" {
-				if marker != "SRC" && marker != "EXAMPLE" {
-					var tmpBuf bytes.Buffer
-					tmpBuf.Write([]byte("<p>\n"))
-					p.inline(&tmpBuf, data)
-					tmpBuf.WriteByte('\n')
-					tmpBuf.Write([]byte("</p>\n"))
-					tmpBlock.Write(tmpBuf.Bytes())
-
-				} else {
-					if !(tmpBlock.Len() == 0 && (marker == "SRC" || marker == "EXAMPLE")) {
-						tmpBlock.WriteByte('\n')
-					}
-					tmpBlock.Write(data)
-				}
-
-			} else {
-				marker = string(matches[2])
-				syntax = string(matches[3])
-			}
-		case isFootnoteDef(data) || inFootNote:
-			if isFootnoteDef(data) {
-				matches := reFootnoteDef.FindSubmatch(data)
-				for i := range p.notes {
-					if p.notes[i].id == string(matches[1]) {
-						p.notes[i].def = string(matches[2])
-						inFootNote = true
-						curFootNoteId = string(matches[1])
-					}
-				}
-			} else if inFootNote {
-				for i := range p.notes {
-					if p.notes[i].id == curFootNoteId {
-						p.notes[i].def += " "
-						p.notes[i].def += string(data)
-					}
-				}
-			}
-		case isTable(data):
-			if inTable != true {
-				inTable = true
-			}
-			tmpBlock.Write(data)
-			tmpBlock.WriteByte('\n')
-		case IsKeyword(data):
-			continue
-		case isComment(data):
-			p.generateComment(&output, data)
-		case isHeadline(data):
-			p.generateHeadline(&output, data)
-		case isDefinitionList(data):
-			if inList != true {
-				listType = "dl"
-				inList = true
-			}
-			var work bytes.Buffer
-			flags := blackfriday.LIST_TYPE_DEFINITION
-			matches := reDefinitionList.FindSubmatch(data)
-			flags |= blackfriday.LIST_TYPE_TERM
-			p.inline(&work, matches[1])
-			p.r.ListItem(&tmpBlock, work.Bytes(), flags)
-			work.Reset()
-			flags &= ^blackfriday.LIST_TYPE_TERM
-			p.inline(&work, matches[2])
-			p.r.ListItem(&tmpBlock, work.Bytes(), flags)
-		case isUnorderedList(data):
-			if inList != true {
-				listType = "ul"
-				inList = true
-			}
-			matches := reUnorderedList.FindSubmatch(data)
-			var work bytes.Buffer
-			p.inline(&work, matches[2])
-			p.r.ListItem(&tmpBlock, work.Bytes(), 0)
-		case isOrderedList(data):
-			if inList != true {
-				listType = "ol"
-				inList = true
-			}
-			matches := reOrderedList.FindSubmatch(data)
-			var work bytes.Buffer
-			tmpBlock.WriteString("<li")
-			if len(matches[2]) > 0 {
-				tmpBlock.WriteString(" value=\"")
-				tmpBlock.Write(matches[2])
-				tmpBlock.WriteString("\"")
-				matches[3] = matches[3][1:]
-			}
-			p.inline(&work, matches[3])
-			tmpBlock.WriteString(">")
-			tmpBlock.Write(work.Bytes())
-			tmpBlock.WriteString("</li>\n")
-		case isHorizontalRule(data):
-			p.r.HRule(&output)
-		case isExampleLine(data):
-			if inParagraph == true {
-				if len(tmpBlock.Bytes()) > 0 {
-					p.generateParagraph(&output, tmpBlock.Bytes()[:len(tmpBlock.Bytes())-1])
-					inParagraph = false
-				}
-				tmpBlock.Reset()
-			}
-			if inFixedWidthArea != true {
-				tmpBlock.WriteString("<pre class=\"example\">\n")
-				inFixedWidthArea = true
-			}
-			matches := reExampleLine.FindSubmatch(data)
-			tmpBlock.Write(matches[1])
-			tmpBlock.WriteString("\n")
-			break
-		default:
-			if inParagraph == false {
-				inParagraph = true
-				if inFixedWidthArea == true {
-					if tmpBlock.Len() > 0 {
-						tmpBlock.WriteString("</pre>")
-						output.Write(tmpBlock.Bytes())
-					}
-					inFixedWidthArea = false
-					tmpBlock.Reset()
-				}
-			}
-			tmpBlock.Write(data)
-			tmpBlock.WriteByte('\n')
-		}
-	}
+	// Parse accepts any input, unrecognised lines become paragraphs.
+	doc, _ := ParseWithOptions(input, opts)
 
-	if len(tmpBlock.Bytes()) > 0 {
-		if inParagraph == true {
-			p.generateParagraph(&output, tmpBlock.Bytes()[:len(tmpBlock.Bytes())-1])
-		} else if inFixedWidthArea == true {
-			tmpBlock.WriteString("</pre>\n")
-			output.Write(tmpBlock.Bytes())
-		}
-	}
+	p := NewParser(renderer)
+	p.collectFootnotes(doc)
+	p.figures = newFigures(doc)
+	p.render(&output, doc.Children)
 
 	// Writing footnote def. list
 	if len(p.notes) > 0 {
 		flags := blackfriday.LIST_ITEM_BEGINNING_OF_LIST
+		notes := p.notes
 		p.r.Footnotes(&output, func() bool {
-			for i := range p.notes {
-				p.r.FootnoteItem(&output, []byte(p.notes[i].id), []byte(p.notes[i].def), flags)
+			for i := range notes {
+				p.r.FootnoteItem(&output, []byte(notes[i].id), p.footnoteDefinition(notes[i].id), flags)
 			}
 			return true
 		})
@@ -329,6 +113,46 @@ func OrgOptions(input []byte, renderer blackfriday.Renderer) []byte {
 	return output.Bytes()
 }
 
+func (p *parser) render(out *bytes.Buffer, nodes []Node) {
+	for idx, n := range nodes {
+		switch n := n.(type) {
+		case *Section:
+			p.render(out, n.Children)
+		case *Headline:
+			p.generateHeadline(out, n)
+			p.render(out, n.Children)
+		case *Paragraph:
+			p.generateParagraph(out, n)
+		case *List:
+			p.generateList(out, n)
+		case *Table:
+			p.generateTable(out, n)
+		case *Block:
+			p.generateBlock(out, n)
+		case *FixedWidth:
+			// a paragraph right after a fixed width area supplies its own newline
+			_, nextIsParagraph := nextNode(nodes, idx).(*Paragraph)
+			p.generateFixedWidth(out, n, !nextIsParagraph)
+		case *Comment:
+			p.generateComment(out, n)
+		case *HorizontalRule:
+			p.r.HRule(out)
+		}
+	}
+}
+
+// xhtml reports whether the renderer writes XHTML.
+func (p *parser) xhtml() bool {
+	return p.r.GetFlags()&blackfriday.HTML_USE_XHTML != 0
+}
+
+func nextNode(nodes []Node, idx int) Node {
+	if idx+1 < len(nodes) {
+		return nodes[idx+1]
+	}
+	return nil
+}
+
 // Org Syntax has been broken up into 4 distinct sections based on
 // the org-syntax draft (http://orgmode.org/worg/dev/org-syntax.html):
 // - Headlines
@@ -338,88 +162,52 @@ func OrgOptions(input []byte, renderer blackfriday.Renderer) []byte {
 
 // Headlines
 func isHeadline(data []byte) bool {
-	if !charMatches(data[0], '*') {
-		return false
-	}
-	level := 0
-	for level < 6 && charMatches(data[level], '*') {
-		level++
-	}
-	return charMatches(data[level], ' ')
+	level := skipChar(data, 0, '*')
+	return level > 0 && level < len(data) && charMatches(data[level], ' ')
 }
 
-func (p *parser) generateHeadline(out *bytes.Buffer, data []byte) {
-	level := 1
-	status := ""
-	priority := ""
-
-	for level < 6 && data[level] == '*' {
-		level++
+func (p *parser) generateHeadline(out *bytes.Buffer, h *Headline) {
+	rawTitle := orgString(h.Title)
+	if len(h.Tags) > 0 {
+		rawTitle += " :" + strings.Join(h.Tags, ":") + ":"
 	}
-
-	start := skipChar(data, level, ' ')
-
-	data = data[start:]
-	i := 0
-
-	// Check if has a status so it can be rendered as a separate span that can be hidden or
-	// modified with CSS classes
-	if hasStatus(data[i:4]) {
-		status = string(data[i:4])
-		i += 5 // one extra character for the next whitespace
-	}
-
-	// Check if the next byte is a priority marker
-	if data[i] == '[' && hasPriority(data[i+1]) {
-		priority = string(data[i+1])
-		i += 4 // for "[c]" + ' '
-	}
-
-	tags, tagsFound := findTags(data, i)
-
-	headlineID := sanitized_anchor_name.Create(string(data[i:]))
+	headlineID := sanitized_anchor_name.Create(rawTitle)
 
 	generate := func() bool {
-		dataEnd := len(data)
-		if tagsFound > 0 {
-			dataEnd = tagsFound
-		}
-
-		headline := bytes.TrimRight(data[i:dataEnd], " \t")
-
-		if status != "" {
-			out.WriteString("<span class=\"todo " + status + "\">" + status + "</span>")
+		// Check if has a status so it can be rendered as a separate span that can be hidden or
+		// modified with CSS classes
+		if h.Keyword != "" {
+			state := "todo"
+			if h.Done {
+				state = "done"
+			}
+			out.WriteString("<span class=\"" + state + " " + h.Keyword + "\">" + h.Keyword + "</span>")
 			out.WriteByte(' ')
 		}
 
-		if priority != "" {
-			out.WriteString("<span class=\"priority " + priority + "\">[" + priority + "]</span>")
+		if h.Priority != nil {
+			out.WriteString("<span class=\"priority " + h.Priority.Value + "\">[#" + escapeHTML(h.Priority.Value) + "]</span>")
 			out.WriteByte(' ')
 		}
 
-		p.inline(out, headline)
+		p.renderInline(out, h.Title)
 
-		if tagsFound > 0 {
-			for _, tag := range tags {
-				out.WriteByte(' ')
-				out.WriteString("<span class=\"tags " + tag + "\">" + tag + "</span>")
-				out.WriteByte(' ')
-			}
+		for _, tag := range h.Tags {
+			out.WriteByte(' ')
+			out.WriteString("<span class=\"tags " + tag + "\">" + tag + "</span>")
+			out.WriteByte(' ')
 		}
 		return true
 	}
 
+	// HTML has no headings below <h6>
+	level := h.Level
+	if level > 6 {
+		level = 6
+	}
 	p.r.Header(out, generate, level, headlineID)
 }
 
-func hasStatus(data []byte) bool {
-	return bytes.Contains(data, []byte("TODO")) || bytes.Contains(data, []byte("DONE"))
-}
-
-func hasPriority(char byte) bool {
-	return (charMatches(char, 'A') || charMatches(char, 'B') || charMatches(char, 'C'))
-}
-
 func findTags(data []byte, start int) ([]string, int) {
 	tags := []string{}
 	tagOpener := 0
@@ -429,7 +217,7 @@ func findTags(data []byte, start int) ([]string, int) {
 			tags = append(tags, string(data[tagMarker+1:tIdx]))
 			tagMarker = tIdx
 		}
-		if data[tIdx] == ':' && tagOpener == 0 && data[tIdx-1] == ' ' {
+		if data[tIdx] == ':' && tagOpener == 0 && tIdx > 0 && data[tIdx-1] == ' ' {
 			tagMarker = tIdx
 			tagOpener = tIdx
 		}
@@ -452,8 +240,20 @@ func isExampleLine(data []byte) bool {
 	return reExampleLine.Match(data)
 }
 
+func (p *parser) generateFixedWidth(out *bytes.Buffer, f *FixedWidth, closingNewline bool) {
+	out.WriteString("<pre class=\"example\">\n")
+	for _, line := range f.Lines {
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	out.WriteString("</pre>")
+	if closingNewline {
+		out.WriteString("\n")
+	}
+}
+
 // ~~ Ordered Lists
-var reOrderedList = regexp.MustCompile(`^(\s*)\d+\.\s+\[?@?(\d*)\]?(.+)`)
+var reOrderedList = regexp.MustCompile(`^(\s*)(\d+\.)\s+(?:\[@(\d+)\]\s+)?(.+)`)
 
 func isOrderedList(data []byte) bool {
 	return reOrderedList.Match(data)
@@ -470,58 +270,95 @@ func isUnorderedList(data []byte) bool {
 var reTableHeaders = regexp.MustCompile(`^[|+-]*$`)
 
 func isTable(data []byte) bool {
-	return charMatches(data[0], '|')
+	return len(data) > 0 && charMatches(data[0], '|')
 }
 
-func (p *parser) generateTable(output *bytes.Buffer, data []byte) {
+func (p *parser) generateTable(output *bytes.Buffer, t *Table) {
 	var table bytes.Buffer
-	rows := bytes.Split(bytes.Trim(data, "\n"), []byte("\n"))
-	hasTableHeaders := len(rows) > 1
-	if len(rows) > 1 {
-		hasTableHeaders = reTableHeaders.Match(rows[1])
-	}
-	tbodySet := false
-
-	for idx, row := range rows {
-		var rowBuff bytes.Buffer
-		if hasTableHeaders && idx == 0 {
-			table.WriteString("<thead>")
-			for _, cell := range bytes.Split(row[1:len(row)-1], []byte("|")) {
-				p.r.TableHeaderCell(&rowBuff, bytes.Trim(cell, " \t"), 0)
+	flags := tableAlignments(t)
+
+	if header := t.Header(); len(header) > 0 {
+		table.WriteString("<thead>")
+		for _, row := range header {
+			var rowBuff bytes.Buffer
+			for i, cell := range row.Cells {
+				var cellBuff bytes.Buffer
+				p.renderInline(&cellBuff, cell.Children)
+				p.r.TableHeaderCell(&rowBuff, cellBuff.Bytes(), flags[i])
 			}
 			p.r.TableRow(&table, rowBuff.Bytes())
-			table.WriteString("</thead>\n")
-		} else if hasTableHeaders && idx == 1 {
-			continue
-		} else {
-			if !tbodySet {
-				table.WriteString("<tbody>")
-				tbodySet = true
+		}
+		table.WriteString("</thead>\n")
+	}
+
+	for _, body := range t.Bodies() {
+		table.WriteString("<tbody>")
+		for _, row := range body {
+			var rowBuff bytes.Buffer
+			for i, cell := range row.Cells {
+				var cellBuff bytes.Buffer
+				p.renderInline(&cellBuff, cell.Children)
+				p.r.TableCell(&rowBuff, cellBuff.Bytes(), flags[i])
 			}
-			if !reTableHeaders.Match(row) {
-				for _, cell := range bytes.Split(row[1:len(row)-1], []byte("|")) {
-					var cellBuff bytes.Buffer
-					p.inline(&cellBuff, bytes.Trim(cell, " \t"))
-					p.r.TableCell(&rowBuff, cellBuff.Bytes(), 0)
-				}
-				p.r.TableRow(&table, rowBuff.Bytes())
+			p.r.TableRow(&table, rowBuff.Bytes())
+		}
+		table.WriteString("</tbody>\n")
+	}
+
+	output.WriteString("\n<table")
+	if t.Affiliated != nil && t.Affiliated.Name != "" {
+		output.WriteString(" id=\"" + escapeHTML(t.Affiliated.Name) + "\"")
+	}
+	output.WriteString(htmlAttributes(t.Affiliated, p.xhtml()) + ">\n")
+	if t.Affiliated != nil && len(t.Affiliated.Caption) > 0 {
+		output.WriteString("<caption>")
+		p.renderInline(output, t.Affiliated.Caption)
+		output.WriteString("</caption>\n")
+	}
+	if columns := t.Columns(); len(columns) > 0 && columns[0].GroupStart {
+		for i, column := range columns {
+			if column.GroupStart && i > 0 {
+				output.WriteString("</colgroup>\n")
 			}
-			if tbodySet && idx == len(rows)-1 {
-				table.WriteString("</tbody>\n")
-				tbodySet = false
+			if column.GroupStart {
+				output.WriteString("<colgroup>\n")
 			}
+			output.WriteString("<col />\n")
 		}
+		output.WriteString("</colgroup>\n")
 	}
-
-	output.WriteString("\n<table>\n")
 	output.Write(table.Bytes())
 	output.WriteString("</table>\n")
 }
 
+// tableAlignments returns the blackfriday alignment flags for the columns of
+// t. Left aligned columns get none, as before.
+func tableAlignments(t *Table) []int {
+	columns := t.Columns()
+	flags := make([]int, len(columns))
+	for i, column := range columns {
+		switch column.Align {
+		case AlignRight:
+			flags[i] = blackfriday.TABLE_ALIGNMENT_RIGHT
+		case AlignCenter:
+			flags[i] = blackfriday.TABLE_ALIGNMENT_CENTER
+		}
+	}
+	return flags
+}
+
 // ~~ Property Drawers
 
 func isPropertyDrawer(data []byte) bool {
-	return bytes.Equal(data, []byte(":PROPERTIES:"))
+	return bytes.EqualFold(bytes.TrimSpace(data), []byte(":PROPERTIES:"))
+}
+
+var reDrawer = regexp.MustCompile(`^\s*:([\w-]+):\s*$`)
+
+// isDrawer reports whether data starts a drawer of any name.
+func isDrawer(data []byte) bool {
+	m := reDrawer.FindSubmatch(data)
+	return m != nil && !bytes.EqualFold(m[1], []byte("END"))
 }
 
 // ~~ Dynamic Blocks
@@ -531,6 +368,54 @@ func isBlock(data []byte) bool {
 	return reBlock.Match(data)
 }
 
+func (p *parser) generateBlock(out *bytes.Buffer, b *Block) {
+	if b.Affiliated != nil && len(b.Affiliated.Caption) > 0 {
+		p.generateFigure(out, b.Affiliated, func(out *bytes.Buffer) {
+			p.generateBlockElement(out, b)
+		})
+		return
+	}
+	p.generateBlockElement(out, b)
+}
+
+func (p *parser) generateBlockElement(out *bytes.Buffer, b *Block) {
+	switch strings.ToUpper(b.Name) {
+	case "QUOTE":
+		var tmpBuf bytes.Buffer
+		p.generateBlockContent(&tmpBuf, b.Children)
+		p.r.BlockQuote(out, tmpBuf.Bytes())
+	case "CENTER":
+		out.WriteString("<center>\n")
+		p.generateBlockContent(out, b.Children)
+		out.WriteString("</center>\n")
+	default:
+		syntax := ""
+		if len(b.Parameters) > 0 {
+			syntax = b.Parameters[0]
+		}
+		p.r.BlockCode(out, []byte(strings.Join(b.Lines, "\n")+"\n"), syntax)
+	}
+}
+
+// generateBlockContent renders the elements of a QUOTE or CENTER block, where
+// every line of a paragraph becomes a paragraph of its own.
+func (p *parser) generateBlockContent(out *bytes.Buffer, nodes []Node) {
+	for _, n := range nodes {
+		para, ok := n.(*Paragraph)
+		if !ok {
+			p.render(out, []Node{n})
+			continue
+		}
+		var work bytes.Buffer
+		p.renderInline(&work, para.Children)
+		for _, line := range bytes.Split(work.Bytes(), []byte("\n")) {
+			out.WriteString("<p>\n")
+			out.Write(line)
+			out.WriteString("\n</p>\n")
+		}
+	}
+}
+
 // ~~ Footnotes
 var reFootnoteDef = regexp.MustCompile(`^\[fn:([\w]+)\] +(.+)`)
 
@@ -538,6 +423,34 @@ func isFootnoteDef(data []byte) bool {
 	return reFootnoteDef.Match(data)
 }
 
+func (p *parser) collectFootnotes(doc *Document) {
+	p.defs = make(map[string]*FootnoteDefinition)
+	Walk(doc, func(n Node) bool {
+		if def, ok := n.(*FootnoteDefinition); ok {
+			p.defs[def.Label] = def
+		}
+		return true
+	})
+}
+
+// footnoteDefinition renders the definition for a footnote id on a single line
+func (p *parser) footnoteDefinition(id string) []byte {
+	def, ok := p.defs[id]
+	if !ok {
+		return []byte("DEFINITION NOT FOUND")
+	}
+	var work bytes.Buffer
+	for _, n := range def.Children {
+		if para, ok := n.(*Paragraph); ok {
+			if work.Len() > 0 {
+				work.WriteByte(' ')
+			}
+			p.renderInline(&work, para.Children)
+		}
+	}
+	return bytes.Replace(work.Bytes(), []byte("\n"), []byte(" "), -1)
+}
+
 // Elements
 // ~~ Keywords
 func IsKeyword(data []byte) bool {
@@ -549,10 +462,10 @@ func isComment(data []byte) bool {
 	return len(data) > 1 && charMatches(data[0], '#') && charMatches(data[1], ' ')
 }
 
-func (p *parser) generateComment(out *bytes.Buffer, data []byte) {
+func (p *parser) generateComment(out *bytes.Buffer, c *Comment) {
 	var work bytes.Buffer
 	work.WriteString("<!-- ")
-	work.Write(data[2:])
+	work.WriteString(c.Value)
 	work.WriteString(" -->")
 	work.WriteByte('\n')
 	out.Write(work.Bytes())
@@ -566,56 +479,124 @@ func isHorizontalRule(data []byte) bool {
 }
 
 // ~~ Paragraphs
-func (p *parser) generateParagraph(out *bytes.Buffer, data []byte) {
+func (p *parser) generateParagraph(out *bytes.Buffer, para *Paragraph) {
+	if img := standaloneImage(para); img != nil {
+		p.generateFigure(out, para.Affiliated, func(out *bytes.Buffer) {
+			p.generateLinkOrImg(out, img)
+		})
+		return
+	}
 	generate := func() bool {
-		p.inline(out, bytes.Trim(data, " "))
+		p.renderInline(out, para.Children)
 		return true
 	}
 	p.r.Paragraph(out, generate)
 }
 
-func (p *parser) generateList(output *bytes.Buffer, data []byte, listType string) {
+// generateFigure wraps what generate renders, a standalone image or a
+// captioned block, in a figure, with its caption and figure number if it has
+// a caption.
+func (p *parser) generateFigure(out *bytes.Buffer, a *Affiliated, generate func(out *bytes.Buffer)) {
+	var content bytes.Buffer
+	generate(&content)
+	if !bytes.HasSuffix(content.Bytes(), []byte("\n")) {
+		content.WriteString("\n")
+	}
+
+	out.WriteString("\n<figure")
+	if a != nil && a.Name != "" {
+		out.WriteString(" id=\"" + escapeHTML(a.Name) + "\"")
+	}
+	out.WriteString(">\n")
+	out.Write(content.Bytes())
+	if a != nil && len(a.Caption) > 0 {
+		out.WriteString("<figcaption>")
+		if number := p.figures.number(a); number > 0 {
+			out.WriteString("<span class=\"figure-number\">Figure " + strconv.Itoa(number) + ":</span> ")
+		}
+		p.renderInline(out, a.Caption)
+		out.WriteString("</figcaption>\n")
+	}
+	out.WriteString("</figure>\n")
+}
+
+func (p *parser) generateList(output *bytes.Buffer, l *List) {
+	var items bytes.Buffer
+	for _, item := range l.Items {
+		p.generateListItem(&items, item)
+	}
 	generateList := func() bool {
 		output.WriteByte('\n')
-		p.inline(output, bytes.Trim(data, " "))
+		output.Write(items.Bytes())
 		return true
 	}
-	switch listType {
-	case "ul":
+	switch l.Kind {
+	case UnorderedList:
 		p.r.List(output, generateList, 0)
-	case "ol":
+	case OrderedList:
 		p.r.List(output, generateList, blackfriday.LIST_TYPE_ORDERED)
-	case "dl":
+	case DescriptiveList:
 		p.r.List(output, generateList, blackfriday.LIST_TYPE_DEFINITION)
 	}
 }
 
-// Objects
-
-func (p *parser) inline(out *bytes.Buffer, data []byte) {
-	i, end := 0, 0
-
-	for i < len(data) {
-		for end < len(data) && p.inlineCallback[data[end]] == nil {
-			end++
-		}
-
-		p.r.Entity(out, data[i:end])
-
-		if end >= len(data) {
-			break
-		}
-		i = end
+func (p *parser) generateListItem(out *bytes.Buffer, item *ListItem) {
+	var work bytes.Buffer
+	switch {
+	case item.Checkbox == "":
+	case item.Checkbox == "X" && p.xhtml():
+		work.WriteString("<input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> ")
+	case item.Checkbox == "X":
+		work.WriteString("<input type=\"checkbox\" checked disabled> ")
+	case p.xhtml():
+		work.WriteString("<input type=\"checkbox\" disabled=\"disabled\" /> ")
+	default:
+		work.WriteString("<input type=\"checkbox\" disabled> ")
+	}
+	first, rest := listItemBody(item)
+	for _, n := range first {
+		p.renderInline(&work, n.(*Paragraph).Children)
+	}
+	p.render(&work, rest)
+
+	switch {
+	case item.Term != nil:
+		var term bytes.Buffer
+		p.renderInline(&term, item.Term)
+		p.r.ListItem(out, term.Bytes(), blackfriday.LIST_TYPE_DEFINITION|blackfriday.LIST_TYPE_TERM)
+		p.r.ListItem(out, work.Bytes(), blackfriday.LIST_TYPE_DEFINITION)
+	case item.Counter != "":
+		out.WriteString("<li value=\"" + item.Counter + "\">")
+		out.Write(work.Bytes())
+		out.WriteString("</li>\n")
+	default:
+		p.r.ListItem(out, work.Bytes(), 0)
+	}
+}
 
-		handler := p.inlineCallback[data[i]]
+// Objects
 
-		if consumed := handler(p, out, data, i); consumed > 0 {
-			i += consumed
-			end = i
-			continue
+func (p *parser) renderInline(out *bytes.Buffer, nodes []Node) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Text:
+			p.r.Entity(out, []byte(n.Value))
+		case *Code:
+			p.r.CodeSpan(out, []byte(n.Value))
+		case *Emphasis:
+			var work bytes.Buffer
+			p.renderInline(&work, n.Children)
+			p.generateEmphasis(out, n.Kind, work.Bytes())
+		case *Link:
+			p.generateLinkOrImg(out, n)
+		case *FootnoteReference:
+			p.notes = append(p.notes, footnotes{n.Label})
+			p.r.FootnoteRef(out, []byte(n.Label), len(p.notes))
+		case *StatisticsCookie:
+			p.r.Entity(out, []byte("["+n.Value+"]"))
+		case *Timestamp:
+			out.WriteString("<time class=\"timestamp\" datetime=\"" + htmlDatetime(n) + "\">" + escapeHTML(timestampString(n)) + "</time>")
 		}
-
-		end = i + 1
 	}
 }
 
@@ -625,7 +606,7 @@ func isAcceptablePreOpeningChar(dataIn, data []byte, offset int) bool {
 	}
 
 	char := dataIn[offset-1]
-	return charMatches(char, ' ') || isPreChar(char)
+	return charMatches(char, ' ') || charMatches(char, '\n') || isPreChar(char)
 }
 
 func isPreChar(char byte) bool {
@@ -633,7 +614,7 @@ func isPreChar(char byte) bool {
 }
 
 func isAcceptablePostClosingChar(char byte) bool {
-	return charMatches(char, ' ') || isTerminatingChar(char)
+	return charMatches(char, ' ') || charMatches(char, '\n') || isTerminatingChar(char)
 }
 
 func isTerminatingChar(char byte) bool {
@@ -658,139 +639,75 @@ func findLastCharInInline(data []byte, char byte) int {
 	return last
 }
 
-func generator(p *parser, out *bytes.Buffer, dataIn []byte, offset int, char byte, doInline bool, renderer func(*bytes.Buffer, []byte)) int {
-	data := dataIn[offset:]
-	c := byte(char)
-	start := 1
-	i := start
-	if len(data) <= 1 {
-		return 0
-	}
-
-	lastCharInside := findLastCharInInline(data, c)
-
-	// Org mode spec says a non-whitespace character must immediately follow.
-	// if the current char is the marker, then there's no text between, not a candidate
-	if isSpace(data[i]) || lastCharInside == i || !isAcceptablePreOpeningChar(dataIn, data, offset) {
-		return 0
-	}
-
-	if lastCharInside > 0 {
-		var work bytes.Buffer
-		if doInline {
-			p.inline(&work, data[start:lastCharInside])
-			renderer(out, work.Bytes())
-		} else {
-			renderer(out, data[start:lastCharInside])
-		}
-		next := lastCharInside + 1
-		return next
-	}
-
-	return 0
-}
-
 // ~~ Text Markup
-func generateVerbatim(p *parser, out *bytes.Buffer, data []byte, offset int) int {
-	return generator(p, out, data, offset, '=', false, p.r.CodeSpan)
-}
-
-func generateCode(p *parser, out *bytes.Buffer, data []byte, offset int) int {
-	return generator(p, out, data, offset, '~', false, p.r.CodeSpan)
-}
-
-func generateEmphasis(p *parser, out *bytes.Buffer, data []byte, offset int) int {
-	return generator(p, out, data, offset, '/', true, p.r.Emphasis)
-}
-
-func generateUnderline(p *parser, out *bytes.Buffer, data []byte, offset int) int {
-	underline := func(out *bytes.Buffer, text []byte) {
+func (p *parser) generateEmphasis(out *bytes.Buffer, kind EmphasisKind, text []byte) {
+	switch kind {
+	case Bold:
+		p.r.DoubleEmphasis(out, text)
+	case Italic:
+		p.r.Emphasis(out, text)
+	case Underline:
 		out.WriteString("<span style=\"text-decoration: underline;\">")
 		out.Write(text)
 		out.WriteString("</span>")
+	case StrikeThrough:
+		p.r.StrikeThrough(out, text)
 	}
-
-	return generator(p, out, data, offset, '_', true, underline)
-}
-
-func generateBold(p *parser, out *bytes.Buffer, data []byte, offset int) int {
-	return generator(p, out, data, offset, '*', true, p.r.DoubleEmphasis)
-}
-
-func generateStrikethrough(p *parser, out *bytes.Buffer, data []byte, offset int) int {
-	return generator(p, out, data, offset, '+', true, p.r.StrikeThrough)
 }
 
 // ~~ Images and Links (inc. Footnote)
-var reLinkOrImg = regexp.MustCompile(`\[\[(.+?)\]\[?(.*?)\]?\]`)
+func (p *parser) generateLinkOrImg(out *bytes.Buffer, l *Link) {
+	hyperlink := []byte(l.URL)
+
+	if bytes.HasPrefix(hyperlink, []byte("file:")) {
+		hyperlink = hyperlink[len("file:"):]
+		if len(l.Description) > 0 {
+			alt := []byte(orgString(l.Description))
+			p.r.Image(out, hyperlink, alt, alt)
+			return
+		}
+		p.r.Image(out, hyperlink, hyperlink, hyperlink)
+		return
+	}
 
-func generateLinkOrImg(p *parser, out *bytes.Buffer, data []byte, offset int) int {
-	data = data[offset+1:]
-	start := 1
-	i := start
-	var hyperlink []byte
-	isImage := false
-	isFootnote := false
-	closedLink := false
-	hasContent := false
-
-	if bytes.Equal(data[0:3], []byte("fn:")) {
-		isFootnote = true
-	} else if data[0] != '[' {
-		return 0
-	}
-
-	if bytes.Equal(data[1:6], []byte("file:")) {
-		isImage = true
-	}
-
-	for i < len(data) {
-		currChar := data[i]
-		switch {
-		case charMatches(currChar, ']') && closedLink == false:
-			if isImage {
-				hyperlink = data[start+5 : i]
-			} else if isFootnote {
-				refid := data[start+2 : i]
-				if bytes.Equal(refid, bytes.Trim(refid, " ")) {
-					p.notes = append(p.notes, footnotes{string(refid), "DEFINITION NOT FOUND"})
-					p.r.FootnoteRef(out, refid, len(p.notes))
-					return i + 2
-				} else {
-					return 0
-				}
-			} else if bytes.Equal(data[i-4:i], []byte(".org")) {
-				orgStart := start
-				if bytes.Equal(data[orgStart:orgStart+2], []byte("./")) {
-					orgStart = orgStart + 1
-				}
-				hyperlink = data[orgStart : i-4]
-			} else {
-				hyperlink = data[start:i]
-			}
-			closedLink = true
-		case charMatches(currChar, '['):
-			start = i + 1
-			hasContent = true
-		case charMatches(currChar, ']') && closedLink == true && hasContent == true && isImage == true:
-			p.r.Image(out, hyperlink, data[start:i], data[start:i])
-			return i + 3
-		case charMatches(currChar, ']') && closedLink == true && hasContent == true:
-			var tmpBuf bytes.Buffer
-			p.inline(&tmpBuf, data[start:i])
-			p.r.Link(out, hyperlink, tmpBuf.Bytes(), tmpBuf.Bytes())
-			return i + 3
-		case charMatches(currChar, ']') && closedLink == true && hasContent == false && isImage == true:
-			p.r.Image(out, hyperlink, hyperlink, hyperlink)
-			return i + 2
-		case charMatches(currChar, ']') && closedLink == true && hasContent == false:
-			p.r.Link(out, hyperlink, hyperlink, hyperlink)
-			return i + 2
+	// only figures and tables get an id to link to
+	target := p.figures.target(l)
+	switch n := target.(type) {
+	case *Table:
+	case *Paragraph:
+		if standaloneImage(n) == nil {
+			target = nil
+		}
+	case *Block:
+		if len(n.Affiliated.Caption) == 0 {
+			target = nil
+		}
+	default:
+		target = nil
+	}
+	if target != nil {
+		hyperlink = []byte("#" + l.URL)
+		if len(l.Description) == 0 {
+			text := []byte(p.figures.label(target))
+			p.r.Link(out, hyperlink, text, text)
+			return
 		}
-		i++
 	}
 
-	return 0
+	if bytes.HasSuffix(hyperlink, []byte(".org")) {
+		hyperlink = hyperlink[:len(hyperlink)-len(".org")]
+		if bytes.HasPrefix(hyperlink, []byte("./")) {
+			hyperlink = hyperlink[1:]
+		}
+	}
+
+	if len(l.Description) > 0 {
+		var tmpBuf bytes.Buffer
+		p.renderInline(&tmpBuf, l.Description)
+		p.r.Link(out, hyperlink, tmpBuf.Bytes(), tmpBuf.Bytes())
+		return
+	}
+	p.r.Link(out, hyperlink, hyperlink, hyperlink)
 }
 
 // Helpers
@@ -155,9 +155,8 @@ func TestGenerateComment(t *testing.T) {
 	p := NewParser(blackfriday.HtmlRenderer(blackfriday.HTML_USE_XHTML, "", ""))
 	var out bytes.Buffer
 	text := "This is a comment and we expect it to look a certain way."
-	orgComment := []byte("# " + text)
 	expected := "<!-- " + text + " -->\n"
-	p.generateComment(&out, orgComment)
+	p.generateComment(&out, &Comment{Value: text})
 	if out.String() != expected {
 		t.Errorf("generateComment(%s) = %s\nwants: %s", text, out.String(), expected)
 	}
@@ -216,6 +215,15 @@ func TestRenderingHeadings(t *testing.T) {
 			"*** *a h3* heading\n",
 			"<h3 id=\"a-h3-heading\"><strong>a h3</strong> heading</h3>\n",
 		},
+
+		"h1-priority": {
+			"* TODO [#A] a task\n",
+			"<h1 id=\"a-task\"><span class=\"todo TODO\">TODO</span> <span class=\"priority A\">[#A]</span> a task</h1>\n",
+		},
+		"h1-done-keyword": {
+			"#+TODO: TODO WAIT | DONE CANCELLED\n* WAIT a task\n* CANCELLED another task\n",
+			"<h1 id=\"a-task\"><span class=\"todo WAIT\">WAIT</span> a task</h1>\n\n<h1 id=\"another-task\"><span class=\"done CANCELLED\">CANCELLED</span> another task</h1>\n",
+		},
 	}
 
 	testOrgCommon(testCases, t)
@@ -232,6 +240,9 @@ func TestRenderingInline(t *testing.T) {
 		"double-newline": {"this string should have\nan inline change.\n\nAnd a new paragraph.\n",
 			"<p>this string should have\nan inline change.</p>\n\n<p>And a new paragraph.</p>\n",
 		},
+		"timestamps": {"meet <2024-01-02 Tue 09:30 +1w> and [2024-01-05 Fri]--[2024-01-07 Sun].\n",
+			"<p>meet <time class=\"timestamp\" datetime=\"2024-01-02T09:30\">&lt;2024-01-02 Tue 09:30 +1w&gt;</time> and <time class=\"timestamp\" datetime=\"2024-01-05\">[2024-01-05 Fri]--[2024-01-07 Sun]</time>.</p>\n",
+		},
 		"emphasis": {
 			"this string /has emphasis text/.\n",
 			"<p>this string <em>has emphasis text</em>.</p>\n",
@@ -399,6 +410,10 @@ func TestRenderingLinksAndImages(t *testing.T) {
 			"- this\n- is\n- an\n- unordered\n- list with [[https://github.com/chaseadamsio/goorgeous][goorgeous by chaseadamsio]] as a link\n",
 			"<ul>\n<li>this</li>\n<li>is</li>\n<li>an</li>\n<li>unordered</li>\n<li>list with <a href=\"https://github.com/chaseadamsio/goorgeous\" title=\"goorgeous by chaseadamsio\">goorgeous by chaseadamsio</a> as a link</li>\n</ul>\n",
 		},
+		"figure-reference": {
+			"see [[cat]]\n\n#+CAPTION: A cat\n#+NAME: cat\n[[file:cat.png]]\n",
+			"<p>see <a href=\"#cat\" title=\"1\">1</a></p>\n\n<figure id=\"cat\">\n<img src=\"cat.png\" alt=\"cat.png\" title=\"cat.png\" />\n<figcaption><span class=\"figure-number\">Figure 1:</span> A cat</figcaption>\n</figure>\n",
+		},
 	}
 
 	testOrgCommon(testCases, t)
@@ -542,6 +557,10 @@ func TestRenderingBlock(t *testing.T) {
 			"   #+BEGIN_CENTER\nthis is a\nmulti-lined centered block.\n   #+END_CENTER\n",
 			"<center>\n<p>\nthis is a\n</p>\n<p>\nmulti-lined centered block.\n</p>\n</center>\n",
 		},
+		"SRC_CAPTION": {
+			"see [[hello]]\n\n#+NAME: hello\n#+CAPTION: Hello /world/\n#+BEGIN_SRC go\nfmt.Println()\n#+END_SRC\n",
+			"<p>see <a href=\"#hello\" title=\"hello\">hello</a></p>\n\n<figure id=\"hello\">\n<pre><code class=\"language-go\">fmt.Println()\n</code></pre>\n<figcaption>Hello <em>world</em></figcaption>\n</figure>\n",
+		},
 	}
 
 	testOrgCommon(testCases, t)
@@ -559,7 +578,7 @@ func TestRenderingTables(t *testing.T) {
 		},
 		"no-table-heading-horizontal-splits": {
 			"|---+---+---|\n| d | e | f |\n|---+---+---|\n| g | h | i |\n|---+---+---|\n",
-			"\n<table>\n<tbody>\n<tr>\n<td>d</td>\n<td>e</td>\n<td>f</td>\n</tr>\n\n<tr>\n<td>g</td>\n<td>h</td>\n<td>i</td>\n</tr>\n</tbody>\n</table>\n",
+			"\n<table>\n<tbody>\n<tr>\n<td>d</td>\n<td>e</td>\n<td>f</td>\n</tr>\n</tbody>\n<tbody>\n<tr>\n<td>g</td>\n<td>h</td>\n<td>i</td>\n</tr>\n</tbody>\n</table>\n",
 		},
 		"table-with-inlined-elements": {
 			"| Format           | Org mode markup syntax |\n| *Bold*           | =*Bold*=               |\n| /Italics/        | =/Italics/=            |\n| _Underline_      | =_Underline_=          |\n| =Verbatim=       | ==Verbatim== |\n| +Strike-through+ | =+Strike-through+=     |\n",
@@ -569,6 +588,26 @@ func TestRenderingTables(t *testing.T) {
 			"| r |\n",
 			"\n<table>\n<tbody>\n<tr>\n<td>r</td>\n</tr>\n</tbody>\n</table>\n",
 		},
+		"table-cookies": {
+			"| / | <  |   >  |\n| <l> | <c> | <r10> |\n| a | b | c |\n| 1 | 2 | 3 |\n",
+			"\n<table>\n<colgroup>\n<col />\n</colgroup>\n<colgroup>\n<col />\n<col />\n</colgroup>\n<tbody>\n<tr>\n<td>a</td>\n<td align=\"center\">b</td>\n<td align=\"right\">c</td>\n</tr>\n\n<tr>\n<td>1</td>\n<td align=\"center\">2</td>\n<td align=\"right\">3</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"table-numeric-column": {
+			"| name | n |\n| x | 1 |\n| y | 2 |\n",
+			"\n<table>\n<tbody>\n<tr>\n<td>name</td>\n<td align=\"right\">n</td>\n</tr>\n\n<tr>\n<td>x</td>\n<td align=\"right\">1</td>\n</tr>\n\n<tr>\n<td>y</td>\n<td align=\"right\">2</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"table-row-groups": {
+			"| a |\n| b |\n|---|\n| c |\n|---|\n| d |\n",
+			"\n<table>\n<thead>\n<tr>\n<th>a</th>\n</tr>\n\n<tr>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>c</td>\n</tr>\n</tbody>\n<tbody>\n<tr>\n<td>d</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"table-formulas": {
+			"| a | 2 | 0 |\n#+TBLFM: $3=$2*2\n",
+			"\n<table>\n<tbody>\n<tr>\n<td>a</td>\n<td align=\"right\">2</td>\n<td align=\"right\">0</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"table-affiliated": {
+			"#+CAPTION: Budget\n#+NAME: budget\n#+ATTR_HTML: :class striped :border 1 :hidden\n| a |\n",
+			"\n<table id=\"budget\" class=\"striped\" border=\"1\" hidden=\"hidden\">\n<caption>Budget</caption>\n<tbody>\n<tr>\n<td>a</td>\n</tr>\n</tbody>\n</table>\n",
+		},
 	}
 
 	testOrgCommon(testCases, t)
@@ -596,6 +635,18 @@ func TestLists(t *testing.T) {
 			"- this\n- is\n- an\n- unordered\n- list\n",
 			"<ul>\n<li>this</li>\n<li>is</li>\n<li>an</li>\n<li>unordered</li>\n<li>list</li>\n</ul>\n",
 		},
+		"checkboxes": {
+			"- [X] done\n- [ ] open\n",
+			"<ul>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> done</li>\n<li><input type=\"checkbox\" disabled=\"disabled\" /> open</li>\n</ul>\n",
+		},
+		"multi-paragraph": {
+			"- this\n  is\n\n  multi-line\n- list\n",
+			"<ul>\n<li>this\nis\n<p>multi-line</p>\n</li>\n<li>list</li>\n</ul>\n",
+		},
+		"nested": {
+			"- this\n  1. is\n  2. nested\n- list\n",
+			"<ul>\n<li>this\n<ol>\n<li>is</li>\n<li>nested</li>\n</ol>\n</li>\n<li>list</li>\n</ul>\n",
+		},
 	}
 
 	testOrgCommon(testCases, t)
@@ -652,6 +703,27 @@ func TestFixedWidthAreas(t *testing.T) {
 	testOrgCommon(testCases, t)
 }
 
+func TestOrgWithParseOptions(t *testing.T) {
+	testCases := map[string]testCase{
+		"table-formulas": {
+			"| a | 2 | 0 |\n#+TBLFM: $3=$2*2\n",
+			"\n<table>\n<tbody>\n<tr>\n<td>a</td>\n<td align=\"right\">2</td>\n<td align=\"right\">4</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"statistics-cookies": {
+			"* shopping [/]\n- [X] milk\n- [ ] eggs\n  - [X] brown [%]\n",
+			"<h1 id=\"shopping-2-2\">shopping [2/2]</h1>\n\n<ul>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> milk</li>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> eggs\n<ul>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> brown [0%]</li>\n</ul>\n</li>\n</ul>\n",
+		},
+	}
+
+	opts := ParseOptions{RecalculateTables: true, UpdateStatistics: true}
+	for caseName, tc := range testCases {
+		out := OrgWithParseOptions([]byte(tc.in), blackfriday.HtmlRenderer(blackfriday.HTML_USE_XHTML, "", ""), opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for OrgWithParseOptions() from %q = %q\nwants: %q", caseName, tc.in, out, tc.expected)
+		}
+	}
+}
+
 func testOrgCommon(testCases map[string]testCase, t *testing.T) {
 	for caseName, tc := range testCases {
 
@@ -0,0 +1,767 @@
+package goorgeous
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/shurcooL/sanitized_anchor_name"
+)
+
+// Renderer writes a Document in an output format.
+type Renderer interface {
+	Render(w io.Writer, doc *Document) error
+}
+
+// HTMLOptions configures an HTMLRenderer.
+type HTMLOptions struct {
+	// XHTML closes void elements XHTML style (<br />) instead of HTML5 style (<br>).
+	XHTML bool
+
+	// ClassPrefix is prepended to every class the renderer writes, except the
+	// language-* classes of source blocks that syntax highlighters look for.
+	ClassPrefix string
+
+	// HeadingOffset is added to the level of every headline, so an offset of 1
+	// renders top level headlines as <h2>.
+	HeadingOffset int
+
+	// Sections wraps every headline and its content in a <section> element.
+	Sections bool
+
+	// HeadlineLevels is the deepest heading level used for headlines, after
+	// HeadingOffset is applied. It is 6, the deepest HTML heading, when zero or
+	// above 6. Deeper headlines are rendered according to DeepHeadlines.
+	HeadlineLevels int
+
+	// DeepHeadlines selects how headlines deeper than HeadlineLevels are rendered.
+	DeepHeadlines DeepHeadlineStyle
+
+	// Planning renders the SCHEDULED, DEADLINE and CLOSED timestamps of
+	// headlines below them. Like org's own exporter, the renderer leaves them
+	// out by default.
+	Planning bool
+
+	// Properties selects how the property drawers of headlines are rendered.
+	Properties PropertyStyle
+
+	// Drawers renders drawers other than property drawers, such as LOGBOOK,
+	// and CLOCK lines. Like org's own exporter, the renderer leaves them out
+	// by default.
+	Drawers bool
+
+	// CheckboxClass is the class of the disabled checkbox inputs written for
+	// the checkboxes of list items. Their items get an on, off or trans class
+	// for checked, unchecked and partially checked boxes, like org's.
+	CheckboxClass string
+}
+
+// DeepHeadlineStyle is the way an HTMLRenderer renders headlines that are
+// deeper than the deepest heading level.
+type DeepHeadlineStyle int
+
+// The ways deep headlines can be rendered.
+const (
+	// DeepHeadlinesHeading renders them as headings of the deepest level with
+	// a level-N class holding their actual level.
+	DeepHeadlinesHeading DeepHeadlineStyle = iota
+
+	// DeepHeadlinesList renders every run of them as a list with an item per
+	// headline, like org's own exporter does.
+	DeepHeadlinesList
+)
+
+// PropertyStyle is the way an HTMLRenderer renders the property drawers of
+// headlines.
+type PropertyStyle int
+
+// The ways property drawers can be rendered.
+const (
+	// PropertiesHidden leaves them out.
+	PropertiesHidden PropertyStyle = iota
+
+	// PropertiesList renders them as a definition list below the heading.
+	PropertiesList
+
+	// PropertiesAttributes renders them as data- attributes of the heading,
+	// such as data-effort="1:00" for an EFFORT property.
+	PropertiesAttributes
+)
+
+// HTMLRenderer renders a Document as HTML.
+type HTMLRenderer struct {
+	HTMLOptions
+}
+
+// NewHTMLRenderer returns an HTMLRenderer using opts.
+func NewHTMLRenderer(opts HTMLOptions) *HTMLRenderer {
+	return &HTMLRenderer{HTMLOptions: opts}
+}
+
+// HTML is the easiest way to turn a byte slice of org content into HTML.
+func HTML(input []byte, opts HTMLOptions) []byte {
+	var out bytes.Buffer
+	doc, _ := Parse(input)
+	NewHTMLRenderer(opts).Render(&out, doc)
+	return out.Bytes()
+}
+
+// Render writes doc to w as HTML.
+func (r *HTMLRenderer) Render(w io.Writer, doc *Document) error {
+	hw := &htmlWriter{
+		HTMLOptions: r.HTMLOptions,
+		defs:        make(map[string]*FootnoteDefinition),
+		notes:       make(map[string]int),
+		ids:         make(map[string]int),
+		figures:     newFigures(doc),
+	}
+	Walk(doc, func(n Node) bool {
+		if def, ok := n.(*FootnoteDefinition); ok {
+			hw.defs[def.Label] = def
+		}
+		return true
+	})
+
+	hw.elements(doc.Children)
+	hw.footnotes()
+
+	_, err := w.Write(hw.out.Bytes())
+	return err
+}
+
+// htmlWriter holds the state of a single Render call.
+type htmlWriter struct {
+	HTMLOptions
+	out bytes.Buffer
+
+	defs map[string]*FootnoteDefinition
+	// notes numbers footnotes in the order they're first referenced
+	notes map[string]int
+	order []string
+	// ids counts the uses of every headline id so duplicates get a suffix
+	ids map[string]int
+
+	figures *figures
+}
+
+func (w *htmlWriter) elements(nodes []Node) {
+	for i := 0; i < len(nodes); {
+		if !w.listed(nodes[i]) {
+			w.element(nodes[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(nodes) && w.listed(nodes[end]) {
+			end++
+		}
+		w.headlineList(nodes[i:end])
+		i = end
+	}
+}
+
+// headlineList writes a run of sibling headlines that are too deep to be
+// headings as a list.
+func (w *htmlWriter) headlineList(headlines []Node) {
+	level := w.level(headlines[0].(*Headline))
+	w.out.WriteString("<ul" + w.class("level-"+strconv.Itoa(level)) + ">\n")
+	for _, n := range headlines {
+		h := n.(*Headline)
+		w.out.WriteString("<li id=\"" + w.headlineID(h) + "\"" + w.propertyAttributes(h) + ">")
+		w.headlineTitle(h)
+		w.out.WriteString("\n")
+		w.planning(h.Planning)
+		w.properties(h)
+		w.elements(h.Children)
+		w.out.WriteString("</li>\n")
+	}
+	w.out.WriteString("</ul>\n")
+}
+
+// level returns the heading level of a headline.
+func (w *htmlWriter) level(h *Headline) int {
+	return h.Level + w.HeadingOffset
+}
+
+// deepestLevel returns the deepest heading level headlines are rendered as.
+func (w *htmlWriter) deepestLevel() int {
+	if w.HeadlineLevels <= 0 || w.HeadlineLevels > 6 {
+		return 6
+	}
+	return w.HeadlineLevels
+}
+
+// listed reports whether n is a headline that is rendered as a list item.
+func (w *htmlWriter) listed(n Node) bool {
+	h, ok := n.(*Headline)
+	return ok && w.DeepHeadlines == DeepHeadlinesList && w.level(h) > w.deepestLevel()
+}
+
+func (w *htmlWriter) element(n Node) {
+	switch n := n.(type) {
+	case *Section:
+		w.elements(n.Children)
+	case *Headline:
+		w.headline(n)
+	case *Paragraph:
+		w.paragraph(n)
+	case *List:
+		w.list(n)
+	case *Table:
+		w.table(n)
+	case *Block:
+		w.block(n)
+	case *FixedWidth:
+		w.out.WriteString("<pre" + w.class("example") + ">")
+		for _, line := range n.Lines {
+			w.out.WriteString(escapeHTML(line) + "\n")
+		}
+		w.out.WriteString("</pre>\n")
+	case *HorizontalRule:
+		w.out.WriteString(w.void("hr") + "\n")
+	case *Drawer:
+		if w.Drawers && !strings.EqualFold(n.Name, "PROPERTIES") {
+			w.out.WriteString("<div" + w.class("drawer", strings.ToLower(n.Name)) + ">\n")
+			w.elements(n.Children)
+			w.out.WriteString("</div>\n")
+		}
+	case *Clock:
+		if w.Drawers {
+			w.out.WriteString("<p" + w.class("clock") + "><span" + w.class("timestamp-kwd") + ">CLOCK:</span> ")
+			w.inline([]Node{n.Timestamp})
+			if !n.Timestamp.End.IsZero() {
+				w.out.WriteString(" <span" + w.class("duration") + ">(" + clockDuration(n.Duration) + ")</span>")
+			}
+			w.out.WriteString("</p>\n")
+		}
+	}
+}
+
+// paragraph writes a paragraph. An image alone in its paragraph is written
+// as a figure, with the paragraph's attributes on the image.
+func (w *htmlWriter) paragraph(p *Paragraph) {
+	if img := standaloneImage(p); img != nil {
+		w.figure(p.Affiliated, true, func(id bool) {
+			w.image(img, w.attributes(p.Affiliated, id))
+			w.out.WriteString("\n")
+		})
+		return
+	}
+	w.out.WriteString("<p" + w.attributes(p.Affiliated, true) + ">")
+	w.inline(p.Children)
+	w.out.WriteString("</p>\n")
+}
+
+// figure writes an element with write, wrapped in a figure if it has a
+// caption or wrap is set. The figure takes the element's id, so write is told
+// whether to write it. Captions of numbered figures start with their number.
+func (w *htmlWriter) figure(a *Affiliated, wrap bool, write func(id bool)) {
+	captioned := a != nil && len(a.Caption) > 0
+	if !captioned && !wrap {
+		write(true)
+		return
+	}
+	id := ""
+	if a != nil && a.Name != "" {
+		id = " id=\"" + escapeHTML(a.Name) + "\""
+	}
+	w.out.WriteString("<figure" + id + ">\n")
+	write(false)
+	if captioned {
+		w.out.WriteString("<figcaption>")
+		if number := w.figures.number(a); number > 0 {
+			w.out.WriteString("<span" + w.class("figure-number") + ">Figure " + strconv.Itoa(number) + ":</span> ")
+		}
+		w.inline(a.Caption)
+		w.out.WriteString("</figcaption>\n")
+	}
+	w.out.WriteString("</figure>\n")
+}
+
+// attributes returns the attributes of an element with the affiliated
+// keywords a: its NAME as id, if id is set, and its ATTR_HTML attributes.
+// Classes from ATTR_HTML are added to the element's own, which get the class
+// prefix.
+func (w *htmlWriter) attributes(a *Affiliated, id bool, classes ...string) string {
+	for i, class := range classes {
+		classes[i] = w.ClassPrefix + class
+	}
+	var idAttr string
+	if a != nil && id && a.Name != "" {
+		idAttr = " id=\"" + escapeHTML(a.Name) + "\""
+	}
+	return idAttr + htmlAttributes(a, w.XHTML, classes...)
+}
+
+func (w *htmlWriter) headline(h *Headline) {
+	level := w.level(h)
+	tag := "h" + strconv.Itoa(level)
+	var class string
+	if level > w.deepestLevel() {
+		tag = "h" + strconv.Itoa(w.deepestLevel())
+		class = w.class("level-" + strconv.Itoa(level))
+	}
+
+	if w.Sections {
+		w.out.WriteString("<section" + w.class("outline-"+strconv.Itoa(level)) + ">\n")
+	}
+
+	w.out.WriteString("<" + tag + " id=\"" + w.headlineID(h) + "\"" + class + w.propertyAttributes(h) + ">")
+	w.headlineTitle(h)
+	w.out.WriteString("</" + tag + ">\n")
+
+	w.planning(h.Planning)
+	w.properties(h)
+	w.elements(h.Children)
+
+	if w.Sections {
+		w.out.WriteString("</section>\n")
+	}
+}
+
+// headlineTitle writes the TODO keyword, priority, title and tags of a headline.
+func (w *htmlWriter) headlineTitle(h *Headline) {
+	if h.Keyword != "" {
+		state := "todo"
+		if h.Done {
+			state = "done"
+		}
+		w.out.WriteString("<span" + w.class(state, h.Keyword) + ">" + escapeHTML(h.Keyword) + "</span> ")
+	}
+	if h.Priority != nil {
+		w.out.WriteString("<span" + w.class("priority", h.Priority.Value) + ">[#" + escapeHTML(h.Priority.Value) + "]</span> ")
+	}
+	w.inline(h.Title)
+	for _, tag := range h.Tags {
+		w.out.WriteString(" <span" + w.class("tag", tag) + ">" + escapeHTML(tag) + "</span>")
+	}
+}
+
+// planning writes the planning line of a headline if Planning is set.
+func (w *htmlWriter) planning(p *Planning) {
+	if p == nil || !w.Planning {
+		return
+	}
+	w.out.WriteString("<p" + w.class("planning") + ">")
+	for i, entry := range planningEntries(p) {
+		if i > 0 {
+			w.out.WriteString(" ")
+		}
+		w.out.WriteString("<span" + w.class("timestamp-kwd") + ">" + entry.keyword + ":</span> ")
+		w.inline([]Node{entry.ts})
+	}
+	w.out.WriteString("</p>\n")
+}
+
+// properties writes the property drawer of a headline as a definition list
+// if Properties is PropertiesList.
+func (w *htmlWriter) properties(h *Headline) {
+	if len(h.Properties) == 0 || w.Properties != PropertiesList {
+		return
+	}
+	w.out.WriteString("<dl" + w.class("properties") + ">\n")
+	for _, name := range propertyNames(h.Properties) {
+		w.out.WriteString("<dt>" + escapeHTML(name) + "</dt>\n<dd>" + escapeHTML(h.Properties[name]) + "</dd>\n")
+	}
+	w.out.WriteString("</dl>\n")
+}
+
+// propertyAttributes returns the property drawer of a headline as data-
+// attributes if Properties is PropertiesAttributes.
+func (w *htmlWriter) propertyAttributes(h *Headline) string {
+	if w.Properties != PropertiesAttributes {
+		return ""
+	}
+	var attrs string
+	for _, name := range propertyNames(h.Properties) {
+		attrs += " data-" + dataAttributeName(name) + "=\"" + escapeHTML(h.Properties[name]) + "\""
+	}
+	return attrs
+}
+
+// dataAttributeName lower cases a property key and replaces the characters
+// that can't be part of an attribute name.
+func dataAttributeName(key string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '-'
+	}, key)
+}
+
+// headlineID returns the anchor for a headline, built from its title and tags
+// the same way OrgOptions builds it, made unique within the document.
+func (w *htmlWriter) headlineID(h *Headline) string {
+	raw := orgString(h.Title)
+	if len(h.Tags) > 0 {
+		raw += " :" + strings.Join(h.Tags, ":") + ":"
+	}
+	id := sanitized_anchor_name.Create(raw)
+	if count := w.ids[id]; count > 0 {
+		w.ids[id]++
+		id += "-" + strconv.Itoa(count)
+	}
+	w.ids[id]++
+	return id
+}
+
+func (w *htmlWriter) list(l *List) {
+	tag := "ul"
+	switch l.Kind {
+	case OrderedList:
+		tag = "ol"
+	case DescriptiveList:
+		tag = "dl"
+	}
+
+	w.out.WriteString("<" + tag + ">\n")
+	for _, item := range l.Items {
+		var attrs string
+		if item.Checkbox != "" {
+			attrs = w.class(checkboxClasses[item.Checkbox])
+		}
+		switch {
+		case l.Kind == DescriptiveList:
+			w.out.WriteString("<dt" + attrs + ">")
+			w.checkbox(item)
+			w.inline(item.Term)
+			w.out.WriteString("</dt>\n<dd>")
+			w.item(item)
+			w.out.WriteString("</dd>\n")
+		default:
+			if item.Counter != "" {
+				attrs += " value=\"" + escapeHTML(item.Counter) + "\""
+			}
+			w.out.WriteString("<li" + attrs + ">")
+			w.checkbox(item)
+			w.item(item)
+			w.out.WriteString("</li>\n")
+		}
+	}
+	w.out.WriteString("</" + tag + ">\n")
+}
+
+// checkboxClasses holds the classes of list items for each checkbox state.
+var checkboxClasses = map[string]string{"X": "on", " ": "off", "-": "trans"}
+
+// checkbox writes the checkbox of a list item, if it has one, as a disabled
+// input. Partially checked boxes are written unchecked.
+func (w *htmlWriter) checkbox(item *ListItem) {
+	if item.Checkbox == "" {
+		return
+	}
+	input := `input type="checkbox"`
+	if w.CheckboxClass != "" {
+		input += w.class(w.CheckboxClass)
+	}
+	checked, disabled := " checked", " disabled"
+	if w.XHTML {
+		checked, disabled = ` checked="checked"`, ` disabled="disabled"`
+	}
+	if item.Checkbox == "X" {
+		input += checked
+	}
+	w.out.WriteString(w.void(input+disabled) + " ")
+}
+
+// item writes the content of a list item: the paragraph on its bullet line
+// inline, followed by any sublists.
+func (w *htmlWriter) item(item *ListItem) {
+	first, rest := listItemBody(item)
+	if len(first) == 0 {
+		w.flow(rest)
+		return
+	}
+	w.inline(first[0].(*Paragraph).Children)
+	if len(rest) > 0 {
+		w.out.WriteByte('\n')
+		w.elements(rest)
+	}
+}
+
+// flow writes the content of a list item or footnote. A lone paragraph is
+// written inline, anything else as elements.
+func (w *htmlWriter) flow(nodes []Node) {
+	if len(nodes) == 1 {
+		if para, ok := nodes[0].(*Paragraph); ok {
+			w.inline(para.Children)
+			return
+		}
+	}
+	w.out.WriteByte('\n')
+	w.elements(nodes)
+}
+
+func (w *htmlWriter) table(t *Table) {
+	columns := t.Columns()
+	w.out.WriteString("<table" + w.attributes(t.Affiliated, true) + ">\n")
+	if t.Affiliated != nil && len(t.Affiliated.Caption) > 0 {
+		w.out.WriteString("<caption>")
+		w.inline(t.Affiliated.Caption)
+		w.out.WriteString("</caption>\n")
+	}
+	w.colgroups(columns)
+
+	if header := t.Header(); len(header) > 0 {
+		w.out.WriteString("<thead>\n")
+		for _, row := range header {
+			w.tableRow(row, "th", columns)
+		}
+		w.out.WriteString("</thead>\n")
+	}
+
+	for _, body := range t.Bodies() {
+		w.out.WriteString("<tbody>\n")
+		for _, row := range body {
+			w.tableRow(row, "td", columns)
+		}
+		w.out.WriteString("</tbody>\n")
+	}
+
+	w.out.WriteString("</table>\n")
+}
+
+// colgroups writes a colgroup for every column group, if the table groups its
+// columns.
+func (w *htmlWriter) colgroups(columns []TableColumn) {
+	if len(columns) == 0 || !columns[0].GroupStart {
+		return
+	}
+	for i, column := range columns {
+		if column.GroupStart {
+			if i > 0 {
+				w.out.WriteString("</colgroup>\n")
+			}
+			w.out.WriteString("<colgroup>\n")
+		}
+		w.out.WriteString(w.void("col"+w.align(column.Align)) + "\n")
+	}
+	w.out.WriteString("</colgroup>\n")
+}
+
+func (w *htmlWriter) tableRow(row *TableRow, cellTag string, columns []TableColumn) {
+	w.out.WriteString("<tr>\n")
+	for i, cell := range row.Cells {
+		w.out.WriteString("<" + cellTag + w.align(columns[i].Align) + ">")
+		w.inline(cell.Children)
+		w.out.WriteString("</" + cellTag + ">\n")
+	}
+	w.out.WriteString("</tr>\n")
+}
+
+// align returns the class attribute for right and centered table columns.
+func (w *htmlWriter) align(align ColumnAlignment) string {
+	switch align {
+	case AlignRight:
+		return w.class("align-right")
+	case AlignCenter:
+		return w.class("align-center")
+	}
+	return ""
+}
+
+func (w *htmlWriter) block(b *Block) {
+	name := strings.ToUpper(b.Name)
+	lines := strings.Join(b.Lines, "\n")
+	if len(b.Lines) > 0 {
+		lines += "\n"
+	}
+
+	switch {
+	case name == "HTML", name == "EXPORT" && len(b.Parameters) > 0 && strings.EqualFold(b.Parameters[0], "html"):
+		w.out.WriteString(lines)
+		return
+	case name == "EXPORT", name == "COMMENT":
+		// content for other exporters and comments aren't part of the HTML
+		return
+	}
+
+	w.figure(b.Affiliated, false, func(id bool) {
+		switch name {
+		case "QUOTE":
+			w.out.WriteString("<blockquote" + w.attributes(b.Affiliated, id) + ">\n")
+			w.elements(b.Children)
+			w.out.WriteString("</blockquote>\n")
+		case "CENTER":
+			w.out.WriteString("<div" + w.attributes(b.Affiliated, id, "center") + ">\n")
+			w.elements(b.Children)
+			w.out.WriteString("</div>\n")
+		case "SRC":
+			w.out.WriteString("<pre" + w.attributes(b.Affiliated, id) + "><code")
+			if len(b.Parameters) > 0 {
+				w.out.WriteString(" class=\"language-" + escapeHTML(b.Parameters[0]) + "\"")
+			}
+			w.out.WriteString(">" + escapeHTML(lines) + "</code></pre>\n")
+		case "VERSE":
+			w.out.WriteString("<p" + w.attributes(b.Affiliated, id, "verse") + ">\n")
+			w.out.WriteString(strings.Replace(escapeHTML(lines), "\n", w.void("br")+"\n", -1))
+			w.out.WriteString("</p>\n")
+		default:
+			w.out.WriteString("<pre" + w.attributes(b.Affiliated, id, strings.ToLower(b.Name)) + ">" + escapeHTML(lines) + "</pre>\n")
+		}
+	})
+}
+
+func (w *htmlWriter) inline(nodes []Node) {
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Text:
+			w.out.WriteString(escapeHTML(n.Value))
+		case *Code:
+			w.out.WriteString("<code>" + escapeHTML(n.Value) + "</code>")
+		case *Emphasis:
+			open, close := emphasisTags(n.Kind)
+			if n.Kind == Underline {
+				open = "<span" + w.class("underline") + ">"
+			}
+			w.out.WriteString(open)
+			w.inline(n.Children)
+			w.out.WriteString(close)
+		case *Link:
+			w.link(n)
+		case *FootnoteReference:
+			w.footnoteReference(n)
+		case *StatisticsCookie:
+			w.out.WriteString("<code>[" + escapeHTML(n.Value) + "]</code>")
+		case *Timestamp:
+			w.out.WriteString("<time" + w.class("timestamp") + " datetime=\"" + htmlDatetime(n) + "\">" + escapeHTML(timestampString(n)) + "</time>")
+		}
+	}
+}
+
+// htmlDatetime returns the datetime attribute of a timestamp: its start as a
+// date, or as a date and time without a time zone when it has a time.
+func htmlDatetime(ts *Timestamp) string {
+	if ts.HasTime {
+		return ts.Time.Format("2006-01-02T15:04")
+	}
+	return ts.Time.Format("2006-01-02")
+}
+
+func emphasisTags(kind EmphasisKind) (string, string) {
+	switch kind {
+	case Bold:
+		return "<strong>", "</strong>"
+	case Italic:
+		return "<em>", "</em>"
+	case StrikeThrough:
+		return "<del>", "</del>"
+	}
+	return "<span>", "</span>"
+}
+
+func (w *htmlWriter) link(l *Link) {
+	url, isImage := linkTarget(l)
+	if isImage {
+		w.image(l, "")
+		return
+	}
+	text := url
+	if n := w.figures.target(l); n != nil {
+		url, text = "#"+l.URL, w.figures.label(n)
+	}
+
+	w.out.WriteString("<a href=\"" + escapeHTML(url) + "\">")
+	if len(l.Description) > 0 {
+		w.inline(l.Description)
+	} else {
+		w.out.WriteString(escapeHTML(text))
+	}
+	w.out.WriteString("</a>")
+}
+
+// image writes an image link as an img element with attrs. Its alt text is
+// the link's description or URL, unless attrs has one.
+func (w *htmlWriter) image(l *Link, attrs string) {
+	url, _ := linkTarget(l)
+	alt := url
+	if len(l.Description) > 0 {
+		alt = orgString(l.Description)
+	}
+	if !strings.Contains(attrs, " alt=") {
+		attrs = " alt=\"" + escapeHTML(alt) + "\"" + attrs
+	}
+	w.out.WriteString(w.void("img src=\"" + escapeHTML(url) + "\"" + attrs))
+}
+
+// linkTarget returns the URL a link points to once exported and whether it is
+// an image. file: links are images, and links to .org files point at the page
+// the file is exported to.
+func linkTarget(l *Link) (string, bool) {
+	url := l.URL
+	if strings.HasPrefix(url, "file:") {
+		return url[len("file:"):], true
+	}
+	if strings.HasSuffix(url, ".org") {
+		url = strings.TrimSuffix(url, ".org")
+		if strings.HasPrefix(url, "./") {
+			url = url[1:]
+		}
+	}
+	return url, false
+}
+
+func (w *htmlWriter) footnoteReference(ref *FootnoteReference) {
+	label := escapeHTML(ref.Label)
+	number, seen := w.notes[ref.Label]
+	if !seen {
+		w.order = append(w.order, ref.Label)
+		number = len(w.order)
+		w.notes[ref.Label] = number
+	}
+
+	w.out.WriteString("<sup" + w.class("footnote-ref"))
+	if !seen {
+		w.out.WriteString(" id=\"fnref:" + label + "\"")
+	}
+	w.out.WriteString("><a href=\"#fn:" + label + "\">" + strconv.Itoa(number) + "</a></sup>")
+}
+
+// footnotes writes the definitions of the referenced footnotes in the order
+// they were first referenced.
+func (w *htmlWriter) footnotes() {
+	if len(w.order) == 0 {
+		return
+	}
+
+	w.out.WriteString("<div" + w.class("footnotes") + ">\n" + w.void("hr") + "\n<ol>\n")
+	// definitions can reference further footnotes, which extend w.order
+	for i := 0; i < len(w.order); i++ {
+		label := escapeHTML(w.order[i])
+		w.out.WriteString("<li id=\"fn:" + label + "\">")
+		if def, ok := w.defs[w.order[i]]; ok {
+			w.flow(def.Children)
+		} else {
+			w.out.WriteString("DEFINITION NOT FOUND")
+		}
+		w.out.WriteString(" <a" + w.class("footnote-return") + " href=\"#fnref:" + label + "\">↩</a></li>\n")
+	}
+	w.out.WriteString("</ol>\n</div>\n")
+}
+
+// class returns a class attribute holding names, each with the class prefix.
+func (w *htmlWriter) class(names ...string) string {
+	for i, name := range names {
+		names[i] = escapeHTML(w.ClassPrefix + name)
+	}
+	return " class=\"" + strings.Join(names, " ") + "\""
+}
+
+// void returns a void element, closed according to the XHTML option.
+func (w *htmlWriter) void(element string) string {
+	if w.XHTML {
+		return "<" + element + " />"
+	}
+	return "<" + element + ">"
+}
+
+var htmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\"", "&quot;")
+
+func escapeHTML(s string) string {
+	return htmlEscaper.Replace(s)
+}
@@ -0,0 +1,305 @@
+package goorgeous
+
+import (
+	"testing"
+)
+
+func testHTML(testCases map[string]testCase, opts HTMLOptions, t *testing.T) {
+	for caseName, tc := range testCases {
+		out := HTML([]byte(tc.in), opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for HTML() from %q = %q\nwants: %q", caseName, tc.in, out, tc.expected)
+		}
+	}
+}
+
+func TestHTMLElements(t *testing.T) {
+	testCases := map[string]testCase{
+		"headline": {
+			"* TODO [#A] a /headline/ :work:home:\ntext\n** DONE child\n",
+			"<h1 id=\"a-headline-work-home\"><span class=\"todo TODO\">TODO</span> <span class=\"priority A\">[#A]</span> a <em>headline</em> <span class=\"tag work\">work</span> <span class=\"tag home\">home</span></h1>\n<p>text</p>\n<h2 id=\"child\"><span class=\"done DONE\">DONE</span> child</h2>\n",
+		},
+		"todo-keywords": {
+			"#+TODO: NEXT | CANCELLED\n* NEXT a\n* CANCELLED b\n",
+			"<h1 id=\"a\"><span class=\"todo NEXT\">NEXT</span> a</h1>\n<h1 id=\"b\"><span class=\"done CANCELLED\">CANCELLED</span> b</h1>\n",
+		},
+		"duplicate-ids": {
+			"* same\n* same\n* same\n",
+			"<h1 id=\"same\">same</h1>\n<h1 id=\"same-1\">same</h1>\n<h1 id=\"same-2\">same</h1>\n",
+		},
+		"paragraphs": {
+			"a <b> & \"c\"\nsecond line\n\nnext\n",
+			"<p>a &lt;b&gt; &amp; &quot;c&quot;\nsecond line</p>\n<p>next</p>\n",
+		},
+		"inline": {
+			"*b* /i/ _u_ +s+ =v= ~c~\n",
+			"<p><strong>b</strong> <em>i</em> <span class=\"underline\">u</span> <del>s</del> <code>v</code> <code>c</code></p>\n",
+		},
+		"timestamps": {
+			"<2024-01-02 Tue 9:30 +1w> [2024-01-05 Fri]--[2024-01-07 Sun]\n",
+			"<p><time class=\"timestamp\" datetime=\"2024-01-02T09:30\">&lt;2024-01-02 Tue 09:30 +1w&gt;</time> <time class=\"timestamp\" datetime=\"2024-01-05\">[2024-01-05 Fri]--[2024-01-07 Sun]</time></p>\n",
+		},
+		"links-and-images": {
+			"[[https://example.com][the *site*]] [[https://example.com]] [[./post.org][post]] [[file:img.png][a gopher]]\n",
+			"<p><a href=\"https://example.com\">the <strong>site</strong></a> <a href=\"https://example.com\">https://example.com</a> <a href=\"/post\">post</a> <img src=\"img.png\" alt=\"a gopher\"></p>\n",
+		},
+		"lists": {
+			"- a\n- b\n\n1. one\n2. [@5] five\n\n- term :: def\n",
+			"<ul>\n<li>a</li>\n<li>b</li>\n</ul>\n<ol>\n<li>one</li>\n<li value=\"5\">five</li>\n</ol>\n<dl>\n<dt>term</dt>\n<dd>def</dd>\n</dl>\n",
+		},
+		"nested-lists": {
+			"- a\n  1. one\n  2. [@5] five\n     - term :: def\n- b\n",
+			"<ul>\n<li>a\n<ol>\n<li>one</li>\n<li value=\"5\">five\n<dl>\n<dt>term</dt>\n<dd>def</dd>\n</dl>\n</li>\n</ol>\n</li>\n<li>b</li>\n</ul>\n",
+		},
+		"multi-line-items": {
+			"1. a\n   wrapped\n\n   second\n   #+BEGIN_QUOTE\n   quoted\n   #+END_QUOTE\n2. b\n",
+			"<ol>\n<li>a\nwrapped\n<p>second</p>\n<blockquote>\n<p>quoted</p>\n</blockquote>\n</li>\n<li>b</li>\n</ol>\n",
+		},
+		"table": {
+			"| a | b |\n|---+---|\n| 1 | <2> |\n|---+---|\n| 3 | 4 |\n",
+			"<table>\n<thead>\n<tr>\n<th class=\"align-right\">a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td class=\"align-right\">1</td>\n<td>&lt;2&gt;</td>\n</tr>\n</tbody>\n<tbody>\n<tr>\n<td class=\"align-right\">3</td>\n<td>4</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"table-cookies": {
+			"| / | <  |   >  |\n| <l> | <c> | <r10> |\n| a | b | c |\n|---+---+---|\n| 1 | 2 | 3 |\n",
+			"<table>\n<colgroup>\n<col>\n</colgroup>\n<colgroup>\n<col class=\"align-center\">\n<col class=\"align-right\">\n</colgroup>\n<thead>\n<tr>\n<th>a</th>\n<th class=\"align-center\">b</th>\n<th class=\"align-right\">c</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>1</td>\n<td class=\"align-center\">2</td>\n<td class=\"align-right\">3</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"table-row-groups": {
+			"| a | b |\n| c | d |\n|---+---|\n| e | f |\n|---+---|\n|---+---|\n| g | h |\n| i | j |\n|---+---|\n",
+			"<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n<tr>\n<th>c</th>\n<th>d</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>e</td>\n<td>f</td>\n</tr>\n</tbody>\n<tbody>\n<tr>\n<td>g</td>\n<td>h</td>\n</tr>\n<tr>\n<td>i</td>\n<td>j</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"blocks": {
+			"#+BEGIN_SRC go\nif a < b {}\n#+END_SRC\n#+BEGIN_EXAMPLE\nan <example>\n#+END_EXAMPLE\n#+BEGIN_QUOTE\nquoted\ntext\n#+END_QUOTE\n#+BEGIN_CENTER\ncentered\n#+END_CENTER\n#+BEGIN_EXPORT html\n<div>raw</div>\n#+END_EXPORT\n#+BEGIN_VERSE\nline one\nline two\n#+END_VERSE\n",
+			"<pre><code class=\"language-go\">if a &lt; b {}\n</code></pre>\n<pre class=\"example\">an &lt;example&gt;\n</pre>\n<blockquote>\n<p>quoted\ntext</p>\n</blockquote>\n<div class=\"center\">\n<p>centered</p>\n</div>\n<div>raw</div>\n<p class=\"verse\">\nline one<br>\nline two<br>\n</p>\n",
+		},
+		"other-elements": {
+			"#+TITLE: not rendered\n# a comment\n:PROPERTIES:\n:ID: 1\n:END:\n-----\n: fixed <width>\n",
+			"<hr>\n<pre class=\"example\">fixed &lt;width&gt;\n</pre>\n",
+		},
+		"footnotes": {
+			"a[fn:1] b[fn:x] c[fn:1] d[fn:missing]\n\n[fn:x] note /x/\n\n[fn:1] note 1\n",
+			"<p>a<sup class=\"footnote-ref\" id=\"fnref:1\"><a href=\"#fn:1\">1</a></sup> b<sup class=\"footnote-ref\" id=\"fnref:x\"><a href=\"#fn:x\">2</a></sup> c<sup class=\"footnote-ref\"><a href=\"#fn:1\">1</a></sup> d<sup class=\"footnote-ref\" id=\"fnref:missing\"><a href=\"#fn:missing\">3</a></sup></p>\n<div class=\"footnotes\">\n<hr>\n<ol>\n<li id=\"fn:1\">note 1 <a class=\"footnote-return\" href=\"#fnref:1\">↩</a></li>\n<li id=\"fn:x\">note <em>x</em> <a class=\"footnote-return\" href=\"#fnref:x\">↩</a></li>\n<li id=\"fn:missing\">DEFINITION NOT FOUND <a class=\"footnote-return\" href=\"#fnref:missing\">↩</a></li>\n</ol>\n</div>\n",
+		},
+	}
+
+	testHTML(testCases, HTMLOptions{}, t)
+}
+
+func TestHTMLOptions(t *testing.T) {
+	in := "* one :tag:\n[[file:a.png]]\n** two\n-----\n* three\n"
+
+	testCases := map[string]struct {
+		opts     HTMLOptions
+		expected string
+	}{
+		"default": {
+			HTMLOptions{},
+			"<h1 id=\"one-tag\">one <span class=\"tag tag\">tag</span></h1>\n<figure>\n<img src=\"a.png\" alt=\"a.png\">\n</figure>\n<h2 id=\"two\">two</h2>\n<hr>\n<h1 id=\"three\">three</h1>\n",
+		},
+		"xhtml": {
+			HTMLOptions{XHTML: true},
+			"<h1 id=\"one-tag\">one <span class=\"tag tag\">tag</span></h1>\n<figure>\n<img src=\"a.png\" alt=\"a.png\" />\n</figure>\n<h2 id=\"two\">two</h2>\n<hr />\n<h1 id=\"three\">three</h1>\n",
+		},
+		"class-prefix": {
+			HTMLOptions{ClassPrefix: "org-"},
+			"<h1 id=\"one-tag\">one <span class=\"org-tag org-tag\">tag</span></h1>\n<figure>\n<img src=\"a.png\" alt=\"a.png\">\n</figure>\n<h2 id=\"two\">two</h2>\n<hr>\n<h1 id=\"three\">three</h1>\n",
+		},
+		"heading-offset": {
+			HTMLOptions{HeadingOffset: 5},
+			"<h6 id=\"one-tag\">one <span class=\"tag tag\">tag</span></h6>\n<figure>\n<img src=\"a.png\" alt=\"a.png\">\n</figure>\n<h6 id=\"two\" class=\"level-7\">two</h6>\n<hr>\n<h6 id=\"three\">three</h6>\n",
+		},
+		"sections": {
+			HTMLOptions{Sections: true, HeadingOffset: 1},
+			"<section class=\"outline-2\">\n<h2 id=\"one-tag\">one <span class=\"tag tag\">tag</span></h2>\n<figure>\n<img src=\"a.png\" alt=\"a.png\">\n</figure>\n<section class=\"outline-3\">\n<h3 id=\"two\">two</h3>\n<hr>\n</section>\n</section>\n<section class=\"outline-2\">\n<h2 id=\"three\">three</h2>\n</section>\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := HTML([]byte(in), tc.opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for HTML() = %q\nwants: %q", caseName, out, tc.expected)
+		}
+	}
+}
+
+func TestHTMLPlanning(t *testing.T) {
+	in := "* TODO task\nDEADLINE: <2024-01-05 Fri> SCHEDULED: <2024-01-02 Tue 9:30>\ntext\n"
+
+	testCases := map[string]struct {
+		opts     HTMLOptions
+		expected string
+	}{
+		"hidden": {
+			HTMLOptions{},
+			"<h1 id=\"task\"><span class=\"todo TODO\">TODO</span> task</h1>\n<p>text</p>\n",
+		},
+		"rendered": {
+			HTMLOptions{Planning: true},
+			"<h1 id=\"task\"><span class=\"todo TODO\">TODO</span> task</h1>\n<p class=\"planning\"><span class=\"timestamp-kwd\">DEADLINE:</span> <time class=\"timestamp\" datetime=\"2024-01-05\">&lt;2024-01-05 Fri&gt;</time> <span class=\"timestamp-kwd\">SCHEDULED:</span> <time class=\"timestamp\" datetime=\"2024-01-02T09:30\">&lt;2024-01-02 Tue 09:30&gt;</time></p>\n<p>text</p>\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := HTML([]byte(in), tc.opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for HTML() = %q\nwants: %q", caseName, out, tc.expected)
+		}
+	}
+}
+
+func TestHTMLProperties(t *testing.T) {
+	in := "* task\n:PROPERTIES:\n:Effort: 1:00\n:Team+: \"x\"\n:END:\n"
+
+	testCases := map[string]struct {
+		opts     HTMLOptions
+		expected string
+	}{
+		"hidden": {
+			HTMLOptions{},
+			"<h1 id=\"task\">task</h1>\n",
+		},
+		"list": {
+			HTMLOptions{Properties: PropertiesList},
+			"<h1 id=\"task\">task</h1>\n<dl class=\"properties\">\n<dt>Effort</dt>\n<dd>1:00</dd>\n<dt>Team+</dt>\n<dd>&quot;x&quot;</dd>\n</dl>\n",
+		},
+		"attributes": {
+			HTMLOptions{Properties: PropertiesAttributes},
+			"<h1 id=\"task\" data-effort=\"1:00\" data-team-=\"&quot;x&quot;\">task</h1>\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := HTML([]byte(in), tc.opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for HTML() = %q\nwants: %q", caseName, out, tc.expected)
+		}
+	}
+}
+
+func TestHTMLDrawers(t *testing.T) {
+	in := "* a\n:PROPERTIES:\n:ID: 1\n:END:\n:LOGBOOK:\nCLOCK: [2024-01-02 Tue 10:00]--[2024-01-02 Tue 11:30] =>  1:30\n:END:\nCLOCK: [2024-01-03 Wed 9:00]\n"
+
+	testCases := map[string]struct {
+		opts     HTMLOptions
+		expected string
+	}{
+		"hidden": {
+			HTMLOptions{},
+			"<h1 id=\"a\">a</h1>\n",
+		},
+		"rendered": {
+			HTMLOptions{Drawers: true},
+			"<h1 id=\"a\">a</h1>\n<div class=\"drawer logbook\">\n<p class=\"clock\"><span class=\"timestamp-kwd\">CLOCK:</span> <time class=\"timestamp\" datetime=\"2024-01-02T10:00\">[2024-01-02 Tue 10:00-11:30]</time> <span class=\"duration\">(1:30)</span></p>\n</div>\n<p class=\"clock\"><span class=\"timestamp-kwd\">CLOCK:</span> <time class=\"timestamp\" datetime=\"2024-01-03T09:00\">[2024-01-03 Wed 09:00]</time></p>\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := HTML([]byte(in), tc.opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for HTML() = %q\nwants: %q", caseName, out, tc.expected)
+		}
+	}
+}
+
+func TestHTMLCheckboxes(t *testing.T) {
+	in := "* tasks [1/3]\n- [X] done\n- [-] partial\n  1. [ ] todo\n\n- [ ] term :: def\n"
+
+	testCases := map[string]struct {
+		opts     HTMLOptions
+		expected string
+	}{
+		"default": {
+			HTMLOptions{},
+			"<h1 id=\"tasks-1-3\">tasks <code>[1/3]</code></h1>\n<ul>\n<li class=\"on\"><input type=\"checkbox\" checked disabled> done</li>\n<li class=\"trans\"><input type=\"checkbox\" disabled> partial\n<ol>\n<li class=\"off\"><input type=\"checkbox\" disabled> todo</li>\n</ol>\n</li>\n</ul>\n<dl>\n<dt class=\"off\"><input type=\"checkbox\" disabled> term</dt>\n<dd>def</dd>\n</dl>\n",
+		},
+		"class-and-xhtml": {
+			HTMLOptions{CheckboxClass: "task", ClassPrefix: "org-", XHTML: true},
+			"<h1 id=\"tasks-1-3\">tasks <code>[1/3]</code></h1>\n<ul>\n<li class=\"org-on\"><input type=\"checkbox\" class=\"org-task\" checked=\"checked\" disabled=\"disabled\" /> done</li>\n<li class=\"org-trans\"><input type=\"checkbox\" class=\"org-task\" disabled=\"disabled\" /> partial\n<ol>\n<li class=\"org-off\"><input type=\"checkbox\" class=\"org-task\" disabled=\"disabled\" /> todo</li>\n</ol>\n</li>\n</ul>\n<dl>\n<dt class=\"org-off\"><input type=\"checkbox\" class=\"org-task\" disabled=\"disabled\" /> term</dt>\n<dd>def</dd>\n</dl>\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := HTML([]byte(in), tc.opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for HTML() = %q\nwants: %q", caseName, out, tc.expected)
+		}
+	}
+}
+
+func TestHTMLAffiliated(t *testing.T) {
+	testCases := map[string]testCase{
+		"table": {
+			"#+NAME: budget\n#+CAPTION: The /budget/\n#+ATTR_HTML: :class striped :border 1\n| a |\n",
+			"<table id=\"budget\" class=\"striped\" border=\"1\">\n<caption>The <em>budget</em></caption>\n<tbody>\n<tr>\n<td>a</td>\n</tr>\n</tbody>\n</table>\n",
+		},
+		"image": {
+			"#+NAME: dog\n#+ATTR_HTML: :width 300 :alt a sleeping dog\n[[file:dog.png]]\n",
+			"<figure id=\"dog\">\n<img src=\"dog.png\" width=\"300\" alt=\"a sleeping dog\">\n</figure>\n",
+		},
+		"image-caption": {
+			"#+CAPTION: A cat\n#+NAME: cat\n#+ATTR_HTML: :class wide\n[[file:cat.png]]\n",
+			"<figure id=\"cat\">\n<img src=\"cat.png\" alt=\"cat.png\" class=\"wide\">\n<figcaption><span class=\"figure-number\">Figure 1:</span> A cat</figcaption>\n</figure>\n",
+		},
+		"paragraph": {
+			"#+NAME: intro\n#+CAPTION: ignored\nsome [[file:a.png]] text\n",
+			"<p id=\"intro\">some <img src=\"a.png\" alt=\"a.png\"> text</p>\n",
+		},
+		"blocks": {
+			"#+NAME: hello\n#+CAPTION: Hello\n#+ATTR_HTML: :data-line 2\n#+BEGIN_SRC go\nfmt.Println()\n#+END_SRC\n#+ATTR_HTML: :class note :hidden\n#+BEGIN_CENTER\nc\n#+END_CENTER\n",
+			"<figure id=\"hello\">\n<pre data-line=\"2\"><code class=\"language-go\">fmt.Println()\n</code></pre>\n<figcaption>Hello</figcaption>\n</figure>\n<div class=\"center note\" hidden>\n<p>c</p>\n</div>\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := HTML([]byte(tc.in), HTMLOptions{})
+		if string(out) != tc.expected {
+			t.Errorf("case %s for HTML() from %q = %q\nwants: %q", caseName, tc.in, out, tc.expected)
+		}
+	}
+}
+
+func TestHTMLFigures(t *testing.T) {
+	in := "See [[dog]], [[cat][the cat]] and [[budget]].\n\n#+CAPTION: A cat\n#+NAME: cat\n[[file:cat.png]]\n\n[[file:x.png]]\n\n#+CAPTION: A dog\n#+NAME: dog\n[[file:dog.png]]\n\n#+NAME: budget\n| a |\n"
+	expected := "<p>See <a href=\"#dog\">2</a>, <a href=\"#cat\">the cat</a> and <a href=\"#budget\">budget</a>.</p>\n" +
+		"<figure id=\"cat\">\n<img src=\"cat.png\" alt=\"cat.png\">\n<figcaption><span class=\"figure-number\">Figure 1:</span> A cat</figcaption>\n</figure>\n" +
+		"<figure>\n<img src=\"x.png\" alt=\"x.png\">\n</figure>\n" +
+		"<figure id=\"dog\">\n<img src=\"dog.png\" alt=\"dog.png\">\n<figcaption><span class=\"figure-number\">Figure 2:</span> A dog</figcaption>\n</figure>\n" +
+		"<table id=\"budget\">\n<tbody>\n<tr>\n<td>a</td>\n</tr>\n</tbody>\n</table>\n"
+
+	out := HTML([]byte(in), HTMLOptions{})
+	if string(out) != expected {
+		t.Errorf("HTML() = %q\nwants: %q", out, expected)
+	}
+}
+
+func TestHTMLDeepHeadlines(t *testing.T) {
+	in := "***** five\n****** six\n******* seven\ntext\n******** eight\n******* seven again\n"
+
+	testCases := map[string]struct {
+		opts     HTMLOptions
+		expected string
+	}{
+		"heading": {
+			HTMLOptions{},
+			"<h5 id=\"five\">five</h5>\n<h6 id=\"six\">six</h6>\n<h6 id=\"seven\" class=\"level-7\">seven</h6>\n<p>text</p>\n<h6 id=\"eight\" class=\"level-8\">eight</h6>\n<h6 id=\"seven-again\" class=\"level-7\">seven again</h6>\n",
+		},
+		"heading-levels": {
+			HTMLOptions{HeadlineLevels: 4, ClassPrefix: "org-"},
+			"<h4 id=\"five\" class=\"org-level-5\">five</h4>\n<h4 id=\"six\" class=\"org-level-6\">six</h4>\n<h4 id=\"seven\" class=\"org-level-7\">seven</h4>\n<p>text</p>\n<h4 id=\"eight\" class=\"org-level-8\">eight</h4>\n<h4 id=\"seven-again\" class=\"org-level-7\">seven again</h4>\n",
+		},
+		"list": {
+			HTMLOptions{DeepHeadlines: DeepHeadlinesList},
+			"<h5 id=\"five\">five</h5>\n<h6 id=\"six\">six</h6>\n<ul class=\"level-7\">\n<li id=\"seven\">seven\n<p>text</p>\n<ul class=\"level-8\">\n<li id=\"eight\">eight\n</li>\n</ul>\n</li>\n<li id=\"seven-again\">seven again\n</li>\n</ul>\n",
+		},
+		"list-with-offset": {
+			HTMLOptions{DeepHeadlines: DeepHeadlinesList, HeadingOffset: 1, Sections: true},
+			"<section class=\"outline-6\">\n<h6 id=\"five\">five</h6>\n<ul class=\"level-7\">\n<li id=\"six\">six\n<ul class=\"level-8\">\n<li id=\"seven\">seven\n<p>text</p>\n<ul class=\"level-9\">\n<li id=\"eight\">eight\n</li>\n</ul>\n</li>\n<li id=\"seven-again\">seven again\n</li>\n</ul>\n</li>\n</ul>\n</section>\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := HTML([]byte(in), tc.opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for HTML() = %q\nwants: %q", caseName, out, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package goorgeous
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ICalendarOptions configures an ICalendarRenderer.
+type ICalendarOptions struct {
+	// Name is the name of the calendar. Without it the document's #+TITLE is
+	// used.
+	Name string
+
+	// Location is the time zone the document's timestamps are in; times are
+	// then written in UTC. Without it times are written as floating times,
+	// which calendars show as they are in the time zone of their user.
+	Location *time.Location
+
+	// Stamp is the time the calendar is made, written as the DTSTAMP of every
+	// component. A zero Stamp means the time of rendering.
+	Stamp time.Time
+}
+
+// ICalendarRenderer renders a Document as an RFC 5545 calendar, the way
+// org-icalendar-export does.
+//
+// Every active timestamp in a headline's title or section becomes a VEVENT,
+// with timestamp ranges as their start and end. Headlines with a TODO keyword
+// and a SCHEDULED or DEADLINE date become VTODOs starting on the scheduled
+// date and due on the deadline. Repeaters become RRULEs. Components are
+// summed up by their headline's title, described by the text of its section
+// and categorized by its tags.
+//
+// UIDs are built from the headline's ID property, prefixed with TS1, TS2 and
+// so on for events and TODO for todos. Headlines without an ID get UIDs from
+// a hash of their outline path and timestamps, numbered when several
+// headlines share those, which stay the same while they do.
+type ICalendarRenderer struct {
+	ICalendarOptions
+}
+
+// NewICalendarRenderer returns an ICalendarRenderer using opts.
+func NewICalendarRenderer(opts ICalendarOptions) *ICalendarRenderer {
+	return &ICalendarRenderer{ICalendarOptions: opts}
+}
+
+// ICalendar is the easiest way to turn a byte slice of org content into an
+// iCalendar file.
+func ICalendar(input []byte, opts ICalendarOptions) []byte {
+	var out bytes.Buffer
+	doc, _ := Parse(input)
+	NewICalendarRenderer(opts).Render(&out, doc)
+	return out.Bytes()
+}
+
+// Render writes doc to w as an iCalendar file.
+func (r *ICalendarRenderer) Render(w io.Writer, doc *Document) error {
+	c := &icalWriter{ICalendarOptions: r.ICalendarOptions, uids: make(map[string]int)}
+	if c.Stamp.IsZero() {
+		c.Stamp = time.Now()
+	}
+	if c.Name == "" {
+		c.Name = documentKeywords(doc)["TITLE"]
+	}
+
+	c.line("BEGIN", "VCALENDAR")
+	c.line("VERSION", "2.0")
+	c.line("PRODID", "-//goorgeous//NONSGML goorgeous//EN")
+	c.line("CALSCALE", "GREGORIAN")
+	if c.Name != "" {
+		c.line("X-WR-CALNAME", icalText(c.Name))
+	}
+	if c.Location != nil {
+		c.line("X-WR-TIMEZONE", c.Location.String())
+	}
+	c.headlines(doc.Children, "")
+	c.line("END", "VCALENDAR")
+
+	_, err := w.Write(c.out.Bytes())
+	return err
+}
+
+// icalWriter holds the state of a single Render call.
+type icalWriter struct {
+	ICalendarOptions
+	out bytes.Buffer
+
+	// uids counts the UIDs hashed so far, to number those of headlines with
+	// the same path and timestamps
+	uids map[string]int
+}
+
+// headlines writes the components of the headlines in nodes and their sub
+// headlines. path holds the titles of their ancestors.
+func (w *icalWriter) headlines(nodes []Node, path string) {
+	for _, n := range nodes {
+		if h, ok := n.(*Headline); ok {
+			titles := path + orgString(h.Title) + "\n"
+			w.headline(h, titles)
+			w.headlines(h.Children, titles)
+		}
+	}
+}
+
+func (w *icalWriter) headline(h *Headline, path string) {
+	for i, ts := range activeTimestamps(h) {
+		w.line("BEGIN", "VEVENT")
+		w.line("UID", w.uid(fmt.Sprintf("TS%d", i+1), h, path+timestampString(ts)))
+		w.line("DTSTAMP", w.Stamp.UTC().Format("20060102T150405Z"))
+		w.time("DTSTART", ts.Time, ts.HasTime)
+		switch {
+		case !ts.End.IsZero() && ts.HasTime:
+			w.time("DTEND", ts.End, true)
+		case !ts.End.IsZero():
+			w.time("DTEND", ts.End.AddDate(0, 0, 1), false)
+		case !ts.HasTime:
+			w.time("DTEND", ts.Time.AddDate(0, 0, 1), false)
+		}
+		w.rrule(ts.Repeater, ts.HasTime)
+		w.describe(h)
+		w.line("END", "VEVENT")
+	}
+
+	p := h.Planning
+	if h.Keyword == "" || p == nil || (p.Scheduled == nil && p.Deadline == nil) {
+		return
+	}
+	w.line("BEGIN", "VTODO")
+	w.line("UID", w.uid("TODO", h, path+planningString(p)))
+	w.line("DTSTAMP", w.Stamp.UTC().Format("20060102T150405Z"))
+	// a recurrence needs a start, which for deadlines alone is the deadline
+	start := p.Scheduled
+	if start == nil && p.Deadline.Repeater != nil {
+		start = p.Deadline
+	}
+	// DTSTART and DUE must both be dates or both be times
+	hasTime := start != nil && start.HasTime || p.Deadline != nil && p.Deadline.HasTime
+	if start != nil {
+		w.time("DTSTART", start.Time, hasTime)
+	}
+	if due := p.Deadline; due != nil {
+		t := due.Time
+		if hasTime && !due.HasTime {
+			// a deadline without a time is due by the end of its day
+			t = t.Add(24*time.Hour - time.Second)
+		}
+		w.time("DUE", t, hasTime)
+	}
+	if start != nil {
+		w.rrule(start.Repeater, hasTime)
+	}
+	w.describe(h)
+	if h.Priority != nil {
+		// iCalendar priorities run from 1, the highest, to 9
+		priority := h.Priority.Rank + 1
+		if priority > 9 {
+			priority = 9
+		}
+		w.line("PRIORITY", fmt.Sprint(priority))
+	}
+	if h.Done {
+		w.line("STATUS", "COMPLETED")
+		if p.Closed != nil {
+			w.line("COMPLETED", w.utc(p.Closed.Time).Format("20060102T150405Z"))
+		}
+	} else {
+		w.line("STATUS", "NEEDS-ACTION")
+	}
+	w.line("END", "VTODO")
+}
+
+// describe writes the SUMMARY, DESCRIPTION and CATEGORIES of h.
+func (w *icalWriter) describe(h *Headline) {
+	var title []Node
+	for _, n := range h.Title {
+		if _, ok := n.(*Timestamp); !ok {
+			title = append(title, n)
+		}
+	}
+	w.line("SUMMARY", icalText(strings.Join(strings.Fields(plainText(title)), " ")))
+
+	if len(h.Children) > 0 {
+		if section, ok := h.Children[0].(*Section); ok {
+			if text := strings.TrimSpace((&PlainTextRenderer{}).blocks(section.Children)); text != "" {
+				w.line("DESCRIPTION", icalText(text))
+			}
+		}
+	}
+
+	if len(h.Tags) > 0 {
+		categories := make([]string, len(h.Tags))
+		for i, tag := range h.Tags {
+			categories[i] = icalText(tag)
+		}
+		w.line("CATEGORIES", strings.Join(categories, ","))
+	}
+}
+
+// time writes a DATE property for a date and a DATE-TIME one for a time.
+func (w *icalWriter) time(name string, t time.Time, hasTime bool) {
+	switch {
+	case !hasTime:
+		w.line(name+";VALUE=DATE", t.Format("20060102"))
+	case w.Location != nil:
+		w.line(name, w.utc(t).Format("20060102T150405Z"))
+	default:
+		w.line(name, t.Format("20060102T150405"))
+	}
+}
+
+// utc returns the time a timestamp's time t is in UTC, taking it to be in
+// Location if there is one.
+func (w *icalWriter) utc(t time.Time) time.Time {
+	if w.Location == nil {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, w.Location).UTC()
+}
+
+var icalFrequencies = map[string]string{
+	"h": "HOURLY",
+	"d": "DAILY",
+	"w": "WEEKLY",
+	"m": "MONTHLY",
+	"y": "YEARLY",
+}
+
+// rrule writes the RRULE of a repeater. Hourly repeaters are left out for
+// dates, which can't repeat within a day.
+func (w *icalWriter) rrule(r *Repeater, hasTime bool) {
+	if r == nil || r.Value <= 0 || r.Unit == "h" && !hasTime {
+		return
+	}
+	rule := "FREQ=" + icalFrequencies[r.Unit]
+	if r.Value > 1 {
+		rule += fmt.Sprintf(";INTERVAL=%d", r.Value)
+	}
+	w.line("RRULE", rule)
+}
+
+// line writes a content line, folded into lines of at most 75 octets.
+func (w *icalWriter) line(name, value string) {
+	line := name + ":" + value
+	for limit := 75; len(line) > limit; limit = 74 {
+		i := limit
+		for !utf8.RuneStart(line[i]) {
+			i--
+		}
+		w.out.WriteString(line[:i] + "\r\n ")
+		line = line[i:]
+	}
+	w.out.WriteString(line + "\r\n")
+}
+
+// uid returns the UID of a component of h. Without an ID property it is a
+// hash of key, the headline's path and timestamps.
+func (w *icalWriter) uid(prefix string, h *Headline, key string) string {
+	if id, ok := h.Property("ID"); ok && id != "" {
+		return prefix + "-" + id
+	}
+	key = prefix + "\n" + key
+	w.uids[key]++
+	if n := w.uids[key]; n > 1 {
+		key += fmt.Sprintf("\n%d", n)
+	}
+	return fmt.Sprintf("%s-%x", prefix, sha1.Sum([]byte(key)))
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+
+// icalText escapes s for a TEXT value.
+func icalText(s string) string {
+	return icalEscaper.Replace(s)
+}
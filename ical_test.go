@@ -0,0 +1,175 @@
+package goorgeous
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestICalendar(t *testing.T) {
+	stamp := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := map[string]struct {
+		in       string
+		opts     ICalendarOptions
+		expected []string
+	}{
+		"event": {
+			"#+TITLE: Work, home\n* Standup <2024-01-01 Mon 9:00 +2w> :team:\n:PROPERTIES:\n:ID: abc\n:END:\nDaily sync; bring notes.\n",
+			ICalendarOptions{Stamp: stamp},
+			[]string{
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"PRODID:-//goorgeous//NONSGML goorgeous//EN",
+				"CALSCALE:GREGORIAN",
+				"X-WR-CALNAME:Work\\, home",
+				"BEGIN:VEVENT",
+				"UID:TS1-abc",
+				"DTSTAMP:20240101T000000Z",
+				"DTSTART:20240101T090000",
+				"RRULE:FREQ=WEEKLY;INTERVAL=2",
+				"SUMMARY:Standup",
+				"DESCRIPTION:Daily sync\\; bring notes.",
+				"CATEGORIES:team",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			},
+		},
+		"ranges": {
+			"* Conference\n:PROPERTIES:\n:ID: conf\n:END:\n<2024-01-03 Wed>--<2024-01-04 Thu>\n\n<2024-01-05 Fri 10:00-12:00>\n",
+			ICalendarOptions{Name: "Events", Stamp: stamp, Location: time.FixedZone("CET", 3600)},
+			[]string{
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"PRODID:-//goorgeous//NONSGML goorgeous//EN",
+				"CALSCALE:GREGORIAN",
+				"X-WR-CALNAME:Events",
+				"X-WR-TIMEZONE:CET",
+				"BEGIN:VEVENT",
+				"UID:TS1-conf",
+				"DTSTAMP:20240101T000000Z",
+				"DTSTART;VALUE=DATE:20240103",
+				"DTEND;VALUE=DATE:20240105",
+				"SUMMARY:Conference",
+				"DESCRIPTION:<2024-01-03 Wed>--<2024-01-04 Thu>\\n\\n<2024-01-05 Fri 10:00-12:",
+				" 00>",
+				"END:VEVENT",
+				"BEGIN:VEVENT",
+				"UID:TS2-conf",
+				"DTSTAMP:20240101T000000Z",
+				"DTSTART:20240105T090000Z",
+				"DTEND:20240105T110000Z",
+				"SUMMARY:Conference",
+				"DESCRIPTION:<2024-01-03 Wed>--<2024-01-04 Thu>\\n\\n<2024-01-05 Fri 10:00-12:",
+				" 00>",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			},
+		},
+		"todos": {
+			"* TODO [#A] Pay rent\nDEADLINE: <2024-01-05 Fri +1m>\n:PROPERTIES:\n:ID: rent\n:END:\n" +
+				"* DONE Report\nCLOSED: [2024-01-02 Tue 10:00] SCHEDULED: <2024-01-01 Mon> DEADLINE: <2024-01-04 Thu>\n:PROPERTIES:\n:ID: report\n:END:\n" +
+				"* TODO Undated\n",
+			ICalendarOptions{Stamp: stamp},
+			[]string{
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"PRODID:-//goorgeous//NONSGML goorgeous//EN",
+				"CALSCALE:GREGORIAN",
+				"BEGIN:VTODO",
+				"UID:TODO-rent",
+				"DTSTAMP:20240101T000000Z",
+				"DTSTART;VALUE=DATE:20240105",
+				"DUE;VALUE=DATE:20240105",
+				"RRULE:FREQ=MONTHLY",
+				"SUMMARY:Pay rent",
+				"PRIORITY:1",
+				"STATUS:NEEDS-ACTION",
+				"END:VTODO",
+				"BEGIN:VTODO",
+				"UID:TODO-report",
+				"DTSTAMP:20240101T000000Z",
+				"DTSTART;VALUE=DATE:20240101",
+				"DUE;VALUE=DATE:20240104",
+				"SUMMARY:Report",
+				"STATUS:COMPLETED",
+				"COMPLETED:20240102T100000Z",
+				"END:VTODO",
+				"END:VCALENDAR",
+			},
+		},
+		"value-types": {
+			"* TODO Call\nSCHEDULED: <2024-01-02 Tue 10:00 +1h> DEADLINE: <2024-01-05 Fri>\n:PROPERTIES:\n:ID: call\n:END:\n" +
+				"* TODO Water\nSCHEDULED: <2024-01-02 Tue +2h>\n:PROPERTIES:\n:ID: water\n:END:\n" +
+				"* Check <2024-01-02 Tue +1h>\n:PROPERTIES:\n:ID: check\n:END:\n",
+			ICalendarOptions{Stamp: stamp},
+			[]string{
+				"BEGIN:VCALENDAR",
+				"VERSION:2.0",
+				"PRODID:-//goorgeous//NONSGML goorgeous//EN",
+				"CALSCALE:GREGORIAN",
+				"BEGIN:VTODO",
+				"UID:TODO-call",
+				"DTSTAMP:20240101T000000Z",
+				"DTSTART:20240102T100000",
+				"DUE:20240105T235959",
+				"RRULE:FREQ=HOURLY",
+				"SUMMARY:Call",
+				"STATUS:NEEDS-ACTION",
+				"END:VTODO",
+				"BEGIN:VTODO",
+				"UID:TODO-water",
+				"DTSTAMP:20240101T000000Z",
+				"DTSTART;VALUE=DATE:20240102",
+				"SUMMARY:Water",
+				"STATUS:NEEDS-ACTION",
+				"END:VTODO",
+				"BEGIN:VEVENT",
+				"UID:TS1-check",
+				"DTSTAMP:20240101T000000Z",
+				"DTSTART;VALUE=DATE:20240102",
+				"DTEND;VALUE=DATE:20240103",
+				"SUMMARY:Check",
+				"END:VEVENT",
+				"END:VCALENDAR",
+			},
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := string(ICalendar([]byte(tc.in), tc.opts))
+		expected := strings.Join(tc.expected, "\r\n") + "\r\n"
+		if out != expected {
+			t.Errorf("case %s: ICalendar(%q) = %q\nwants: %q", caseName, tc.in, out, expected)
+		}
+	}
+}
+
+func TestICalendarUIDs(t *testing.T) {
+	in := "* Team\n** TODO Weekly review\nSCHEDULED: <2024-01-01 Mon>\n** TODO Weekly review\nSCHEDULED: <2024-01-01 Mon>\n" +
+		"* Home\n** TODO Weekly review\nSCHEDULED: <2024-01-01 Mon>\n** TODO Weekly review\nSCHEDULED: <2024-01-08 Mon>\n" +
+		"** Weekly review <2024-01-01 Mon>\n** Weekly review <2024-01-01 Mon>\n"
+	opts := ICalendarOptions{Stamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	var uids []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(ICalendar([]byte(in), opts)), "\r\n") {
+		if strings.HasPrefix(line, "UID:") {
+			if seen[line] {
+				t.Errorf("ICalendar() repeats %s", line)
+			}
+			seen[line] = true
+			uids = append(uids, line)
+		}
+	}
+	if len(uids) != 6 {
+		t.Errorf("ICalendar() writes %d UIDs\nwants: 6", len(uids))
+	}
+
+	// UIDs stay the same between exports
+	again := string(ICalendar([]byte(in), opts))
+	for _, uid := range uids {
+		if !strings.Contains(again, uid+"\r\n") {
+			t.Errorf("ICalendar() changed %s", uid)
+		}
+	}
+}
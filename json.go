@@ -0,0 +1,248 @@
+package goorgeous
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MarshalJSON encodes the document tree as JSON. Every node becomes an object
+// with a "type" member holding the node's Go type name (e.g. "Headline")
+// followed by its non-empty fields, keyed by their names with a lower case
+// first letter. Fields holding nodes, such as "children", "title" or "items",
+// hold arrays of node objects:
+//
+//	{"type":"Document","children":[
+//	  {"type":"Headline","level":1,"keyword":"TODO","title":[{"type":"Text","value":"a"}]}
+//	]}
+//
+// The kinds of lists and emphasis are written as lower case names like
+// "ordered" or "bold".
+func (d *Document) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	if err := encodeNode(&out, d); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a document tree encoded by MarshalJSON.
+func (d *Document) UnmarshalJSON(data []byte) error {
+	n, err := decodeNode(data)
+	if err != nil {
+		return err
+	}
+	doc, ok := n.(*Document)
+	if !ok {
+		return fmt.Errorf("goorgeous: JSON holds a %s, not a Document", nodeTypeName(n))
+	}
+	*d = *doc
+	return nil
+}
+
+// nodeTypes maps the type names used in JSON to node types.
+var nodeTypes = make(map[string]reflect.Type)
+
+func init() {
+	for _, n := range []Node{
+		&Document{}, &Headline{}, &Section{}, &Paragraph{}, &List{}, &ListItem{},
+		&Table{}, &TableRow{}, &TableCell{}, &Block{}, &Drawer{}, &FootnoteDefinition{},
+		&FixedWidth{}, &Keyword{}, &Comment{}, &HorizontalRule{}, &Text{}, &Emphasis{},
+		&Code{}, &Link{}, &FootnoteReference{}, &Planning{}, &Timestamp{},
+		&Clock{}, &StatisticsCookie{}, &Affiliated{},
+	} {
+		nodeTypes[nodeTypeName(n)] = reflect.TypeOf(n).Elem()
+	}
+}
+
+var nodeInterface = reflect.TypeOf((*Node)(nil)).Elem()
+var textMarshaler = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func nodeTypeName(n Node) string {
+	return reflect.TypeOf(n).Elem().Name()
+}
+
+// jsonName returns the JSON key for a field name: Level becomes level and
+// URL becomes url.
+func jsonName(field string) string {
+	if strings.ToUpper(field) == field {
+		return strings.ToLower(field)
+	}
+	return strings.ToLower(field[:1]) + field[1:]
+}
+
+func encodeNode(out *bytes.Buffer, n Node) error {
+	v := reflect.ValueOf(n).Elem()
+	out.WriteString(`{"type":"` + v.Type().Name() + `"`)
+
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if field.PkgPath != "" || (isEmptyValue(value) && !isKind(field.Type)) {
+			continue
+		}
+		out.WriteString(`,"` + jsonName(field.Name) + `":`)
+
+		switch {
+		case field.Type.Implements(nodeInterface):
+			if err := encodeNode(out, value.Interface().(Node)); err != nil {
+				return err
+			}
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Implements(nodeInterface):
+			out.WriteByte('[')
+			for j := 0; j < value.Len(); j++ {
+				if j > 0 {
+					out.WriteByte(',')
+				}
+				if err := encodeNode(out, value.Index(j).Interface().(Node)); err != nil {
+					return err
+				}
+			}
+			out.WriteByte(']')
+		default:
+			data, err := json.Marshal(value.Interface())
+			if err != nil {
+				return err
+			}
+			out.Write(data)
+		}
+	}
+
+	out.WriteByte('}')
+	return nil
+}
+
+// isKind reports whether t is an enumeration such as ListKind, whose zero
+// value is written like any other.
+func isKind(t reflect.Type) bool {
+	return t.Kind() == reflect.Int && t.Implements(textMarshaler)
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return v.Type().Comparable() && v.Interface() == reflect.Zero(v.Type()).Interface()
+}
+
+func decodeNode(data []byte) (Node, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var name string
+	if err := json.Unmarshal(fields["type"], &name); err != nil {
+		return nil, fmt.Errorf("goorgeous: node without a type: %s", data)
+	}
+	t, ok := nodeTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("goorgeous: unknown node type %q", name)
+	}
+
+	v := reflect.New(t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		raw, ok := fields[jsonName(field.Name)]
+		if field.PkgPath != "" || !ok {
+			continue
+		}
+		if err := decodeField(v.Elem().Field(i), raw); err != nil {
+			return nil, fmt.Errorf("goorgeous: %s.%s: %s", name, field.Name, err)
+		}
+	}
+	return v.Interface().(Node), nil
+}
+
+func decodeField(field reflect.Value, raw json.RawMessage) error {
+	switch {
+	case field.Type().Implements(nodeInterface):
+		return decodeInto(field, raw)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Implements(nodeInterface):
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeInto(slice.Index(i), item); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return json.Unmarshal(raw, field.Addr().Interface())
+}
+
+// decodeInto decodes a node into v, which holds either a Node or a pointer
+// to a specific node type.
+func decodeInto(v reflect.Value, raw json.RawMessage) error {
+	n, err := decodeNode(raw)
+	if err != nil {
+		return err
+	}
+	nv := reflect.ValueOf(n)
+	if !nv.Type().AssignableTo(v.Type()) {
+		return fmt.Errorf("a %s can't hold a %s", v.Type(), nodeTypeName(n))
+	}
+	v.Set(nv)
+	return nil
+}
+
+var listKindNames = map[ListKind]string{
+	UnorderedList:   "unordered",
+	OrderedList:     "ordered",
+	DescriptiveList: "descriptive",
+}
+
+func (k ListKind) String() string {
+	return listKindNames[k]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k ListKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *ListKind) UnmarshalText(text []byte) error {
+	for kind, name := range listKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("goorgeous: unknown list kind %q", text)
+}
+
+var emphasisKindNames = map[EmphasisKind]string{
+	Bold:          "bold",
+	Italic:        "italic",
+	Underline:     "underline",
+	StrikeThrough: "strike-through",
+}
+
+func (k EmphasisKind) String() string {
+	return emphasisKindNames[k]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (k EmphasisKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (k *EmphasisKind) UnmarshalText(text []byte) error {
+	for kind, name := range emphasisKindNames {
+		if name == string(text) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("goorgeous: unknown emphasis kind %q", text)
+}
@@ -0,0 +1,57 @@
+package goorgeous
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	doc, _ := Parse([]byte("* TODO [#A] a /b/ :x:\n1. one\n"))
+	expected := `{"type":"Document","children":[` +
+		`{"type":"Headline","level":1,"keyword":"TODO","priority":{"value":"A","rank":0},"title":[{"type":"Text","value":"a "},{"type":"Emphasis","kind":"italic","children":[{"type":"Text","value":"b"}]}],"tags":["x"],"children":[` +
+		`{"type":"Section","children":[{"type":"List","kind":"ordered","items":[{"type":"ListItem","bullet":"1.","children":[{"type":"Paragraph","children":[{"type":"Text","value":"one"}]}]}]}]}]}]}`
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %s", err)
+	}
+	if string(out) != expected {
+		t.Errorf("json.Marshal(doc) = %s\nwants: %s", out, expected)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	in, err := ioutil.ReadFile("./testdata/test.org")
+	if err != nil {
+		t.Fatalf("couldn't read test.org: %s", err)
+	}
+	doc, _ := Parse(in)
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %s", err)
+	}
+	var decoded Document
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %s", err)
+	}
+	if !reflect.DeepEqual(decoded.Children, doc.Children) {
+		t.Errorf("json.Unmarshal(json.Marshal(doc)) doesn't match doc")
+	}
+
+	errorCases := map[string]string{
+		"not-a-document": `{"type":"Paragraph"}`,
+		"unknown-type":   `{"type":"Document","children":[{"type":"Unknown"}]}`,
+		"no-type":        `{"type":"Document","children":[{}]}`,
+		"wrong-child":    `{"type":"List","items":[{"type":"Text"}]}`,
+		"unknown-kind":   `{"type":"Document","children":[{"type":"List","kind":"round"}]}`,
+	}
+	for caseName, in := range errorCases {
+		var doc Document
+		if err := json.Unmarshal([]byte(in), &doc); err == nil {
+			t.Errorf("case %s: json.Unmarshal(%s) returned no error", caseName, in)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package goorgeous
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LaTeXOptions configures a LaTeXRenderer.
+type LaTeXOptions struct {
+	// Minted writes source blocks as minted environments instead of listings.
+	Minted bool
+
+	// Standalone wraps the output in a complete article document, with the
+	// packages it needs and a title built from the TITLE, AUTHOR and DATE
+	// keywords.
+	Standalone bool
+}
+
+// LaTeXRenderer renders a Document as LaTeX.
+//
+// The output uses hyperref for links, graphicx for images, ulem for
+// underlined and struck through text and listings or minted for source
+// blocks; Standalone documents load them.
+type LaTeXRenderer struct {
+	LaTeXOptions
+}
+
+// NewLaTeXRenderer returns a LaTeXRenderer using opts.
+func NewLaTeXRenderer(opts LaTeXOptions) *LaTeXRenderer {
+	return &LaTeXRenderer{LaTeXOptions: opts}
+}
+
+// LaTeX is the easiest way to turn a byte slice of org content into LaTeX.
+func LaTeX(input []byte, opts LaTeXOptions) []byte {
+	var out bytes.Buffer
+	doc, _ := Parse(input)
+	NewLaTeXRenderer(opts).Render(&out, doc)
+	return out.Bytes()
+}
+
+// Render writes doc to w as LaTeX.
+func (r *LaTeXRenderer) Render(w io.Writer, doc *Document) error {
+	lw := &latexWriter{
+		LaTeXOptions: r.LaTeXOptions,
+		defs:         make(map[string]*FootnoteDefinition),
+		notes:        make(map[string]int),
+	}
+	Walk(doc, func(n Node) bool {
+		if def, ok := n.(*FootnoteDefinition); ok {
+			lw.defs[def.Label] = def
+		}
+		return true
+	})
+
+	body := lw.blocks(doc.Children)
+	if r.Standalone {
+		body = lw.preamble() + "\\begin{document}\n\n" + lw.title(doc) + body + "\n\\end{document}\n"
+	}
+
+	_, err := io.WriteString(w, body)
+	return err
+}
+
+// latexWriter holds the state of a single Render call.
+type latexWriter struct {
+	LaTeXOptions
+
+	defs map[string]*FootnoteDefinition
+	// notes numbers footnotes in the order they're first referenced
+	notes map[string]int
+
+	// enumerations is the number of ordered lists being written, one
+	// nested in the other
+	enumerations int
+}
+
+func (w *latexWriter) preamble() string {
+	highlighting := "listings"
+	if w.Minted {
+		highlighting = "minted"
+	}
+	return "\\documentclass{article}\n" +
+		"\\usepackage[utf8]{inputenc}\n" +
+		"\\usepackage{graphicx}\n" +
+		"\\usepackage{amssymb}\n" +
+		"\\usepackage[normalem]{ulem}\n" +
+		"\\usepackage{" + highlighting + "}\n" +
+		"\\usepackage{hyperref}\n"
+}
+
+// title returns the title block for the TITLE, AUTHOR and DATE keywords of
+// doc's leading section.
+func (w *latexWriter) title(doc *Document) string {
+	keywords := documentKeywords(doc)
+	if keywords["TITLE"] == "" {
+		return ""
+	}
+
+	var out bytes.Buffer
+	for _, key := range []string{"TITLE", "AUTHOR", "DATE"} {
+		if value, ok := keywords[key]; ok {
+			out.WriteString("\\" + strings.ToLower(key) + "{" + escapeLaTeX(value) + "}\n")
+		}
+	}
+	out.WriteString("\\maketitle\n\n")
+	return out.String()
+}
+
+// documentKeywords returns the keywords in doc's leading section, keyed by
+// their upper case names.
+func documentKeywords(doc *Document) map[string]string {
+	keywords := make(map[string]string)
+	if len(doc.Children) > 0 {
+		if section, ok := doc.Children[0].(*Section); ok {
+			for _, n := range section.Children {
+				if k, ok := n.(*Keyword); ok {
+					keywords[strings.ToUpper(k.Key)] = k.Value
+				}
+			}
+		}
+	}
+	return keywords
+}
+
+// blocks returns the LaTeX for a run of elements, separated by blank lines.
+func (w *latexWriter) blocks(nodes []Node) string {
+	var blocks []string
+	for _, n := range nodes {
+		blocks = append(blocks, w.element(n))
+	}
+	return joinBlocks(blocks)
+}
+
+// latexSections holds the sectioning commands for each headline level.
+// Deeper headlines use the last one.
+var latexSections = []string{"section", "subsection", "subsubsection", "paragraph", "subparagraph"}
+
+func (w *latexWriter) element(n Node) string {
+	switch n := n.(type) {
+	case *Section:
+		return w.blocks(n.Children)
+	case *Headline:
+		return joinBlocks([]string{w.headline(n), w.blocks(n.Children)})
+	case *Paragraph:
+		return w.inline(n.Children) + "\n"
+	case *List:
+		return w.list(n)
+	case *Table:
+		return w.table(n)
+	case *Block:
+		return w.block(n)
+	case *FixedWidth:
+		return "\\begin{verbatim}\n" + strings.Join(n.Lines, "\n") + "\n\\end{verbatim}\n"
+	case *HorizontalRule:
+		return "\\noindent\\rule{\\linewidth}{0.5pt}\n"
+	}
+	return ""
+}
+
+func (w *latexWriter) headline(h *Headline) string {
+	level := h.Level
+	if level > len(latexSections) {
+		level = len(latexSections)
+	}
+
+	var title string
+	if h.Keyword != "" {
+		title += "\\textbf{" + escapeLaTeX(h.Keyword) + "} "
+	}
+	if h.Priority != nil {
+		title += "\\framebox{\\#" + escapeLaTeX(h.Priority.Value) + "} "
+	}
+	title += w.inline(h.Title)
+	if len(h.Tags) > 0 {
+		title += "\\hfill{}\\textsc{" + escapeLaTeX(strings.Join(h.Tags, ":")) + "}"
+	}
+	return "\\" + latexSections[level-1] + "{" + title + "}\n"
+}
+
+// latexCheckboxes holds the symbols of checkboxes, which label their items
+// like in org's LaTeX export.
+var latexCheckboxes = map[string]string{"X": "$\\boxtimes$", " ": "$\\square$", "-": "$\\boxminus$"}
+
+// enumCounters are the counters of enumerate lists, nested up to four deep.
+var enumCounters = []string{"enumi", "enumii", "enumiii", "enumiv"}
+
+func (w *latexWriter) list(l *List) string {
+	env := "itemize"
+	switch l.Kind {
+	case OrderedList:
+		env = "enumerate"
+	case DescriptiveList:
+		env = "description"
+	}
+
+	var counter string
+	if l.Kind == OrderedList {
+		w.enumerations++
+		defer func() { w.enumerations-- }()
+		counter = enumCounters[len(enumCounters)-1]
+		if w.enumerations <= len(enumCounters) {
+			counter = enumCounters[w.enumerations-1]
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("\\begin{" + env + "}\n")
+	for _, item := range l.Items {
+		if n, err := strconv.Atoi(item.Counter); err == nil && l.Kind == OrderedList {
+			out.WriteString("\\setcounter{" + counter + "}{" + strconv.Itoa(n-1) + "}\n")
+		}
+		body := w.blocks(item.Children)
+		if first, rest := listItemBody(item); len(rest) == 1 && isList(rest[0]) {
+			body = w.blocks(first) + w.blocks(rest)
+		}
+		out.WriteString("\\item")
+		switch {
+		case l.Kind == DescriptiveList:
+			out.WriteString("[{" + w.inline(item.Term) + "}]")
+			if item.Checkbox != "" {
+				body = latexCheckboxes[item.Checkbox] + " " + body
+			}
+		case item.Checkbox != "":
+			out.WriteString("[{" + latexCheckboxes[item.Checkbox] + "}]")
+		}
+		out.WriteString(" " + body)
+	}
+	out.WriteString("\\end{" + env + "}\n")
+	return out.String()
+}
+
+func (w *latexWriter) table(t *Table) string {
+	var out bytes.Buffer
+	spec := ""
+	for i, column := range t.Columns() {
+		if column.GroupStart && i > 0 {
+			spec += "|"
+		}
+		spec += map[ColumnAlignment]string{AlignLeft: "l", AlignRight: "r", AlignCenter: "c"}[column.Align]
+	}
+	out.WriteString("\\begin{center}\n\\begin{tabular}{" + spec + "}\n")
+	for _, row := range dataRows(t) {
+		if row.Rule {
+			out.WriteString("\\hline\n")
+			continue
+		}
+		cells := make([]string, len(row.Cells))
+		for i, cell := range row.Cells {
+			cells[i] = w.inline(cell.Children)
+		}
+		out.WriteString(strings.Join(cells, " & ") + " \\\\\n")
+	}
+	out.WriteString("\\end{tabular}\n\\end{center}\n")
+	return out.String()
+}
+
+func (w *latexWriter) block(b *Block) string {
+	name := strings.ToUpper(b.Name)
+	lines := strings.Join(b.Lines, "\n")
+	if len(b.Lines) > 0 {
+		lines += "\n"
+	}
+	format := ""
+	if len(b.Parameters) > 0 {
+		format = strings.ToLower(b.Parameters[0])
+	}
+
+	switch {
+	case name == "QUOTE", name == "CENTER":
+		env := strings.ToLower(name)
+		return "\\begin{" + env + "}\n" + w.blocks(b.Children) + "\\end{" + env + "}\n"
+	case name == "SRC" && w.Minted:
+		if format == "" {
+			format = "text"
+		}
+		return "\\begin{minted}{" + format + "}\n" + lines + "\\end{minted}\n"
+	case name == "SRC":
+		options := ""
+		if format != "" {
+			options = "[language=" + format + "]"
+		}
+		return "\\begin{lstlisting}" + options + "\n" + lines + "\\end{lstlisting}\n"
+	case name == "LATEX", name == "EXPORT" && format == "latex":
+		return lines
+	case name == "EXPORT", name == "COMMENT":
+		return ""
+	case name == "VERSE":
+		verse := strings.Replace(strings.TrimSuffix(escapeLaTeX(lines), "\n"), "\n", " \\\\\n", -1)
+		return "\\begin{verse}\n" + verse + "\n\\end{verse}\n"
+	}
+	return "\\begin{verbatim}\n" + lines + "\\end{verbatim}\n"
+}
+
+func (w *latexWriter) inline(nodes []Node) string {
+	var out bytes.Buffer
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Text:
+			out.WriteString(escapeLaTeX(n.Value))
+		case *Code:
+			out.WriteString("\\texttt{" + escapeLaTeX(n.Value) + "}")
+		case *Emphasis:
+			out.WriteString("\\" + latexEmphasis[n.Kind] + "{" + w.inline(n.Children) + "}")
+		case *Link:
+			out.WriteString(w.link(n))
+		case *FootnoteReference:
+			out.WriteString(w.footnote(n))
+		case *StatisticsCookie:
+			out.WriteString("\\texttt{[" + escapeLaTeX(n.Value) + "]}")
+		case *Timestamp:
+			out.WriteString("\\textit{" + angleEscaper.Replace(escapeLaTeX(timestampString(n))) + "}")
+		}
+	}
+	return out.String()
+}
+
+var latexEmphasis = map[EmphasisKind]string{
+	Bold:          "textbf",
+	Italic:        "emph",
+	Underline:     "uline",
+	StrikeThrough: "sout",
+}
+
+func (w *latexWriter) link(l *Link) string {
+	url, isImage := linkTarget(l)
+	if isImage {
+		return "\\includegraphics[width=.9\\linewidth]{" + url + "}"
+	}
+	if len(l.Description) > 0 {
+		return "\\href{" + escapeURL(url) + "}{" + w.inline(l.Description) + "}"
+	}
+	return "\\url{" + escapeURL(url) + "}"
+}
+
+// footnote writes the definition of a footnote at its first reference, and
+// a mark pointing back to it at every later one.
+func (w *latexWriter) footnote(ref *FootnoteReference) string {
+	if number, seen := w.notes[ref.Label]; seen {
+		return "\\footnotemark[" + strconv.Itoa(number) + "]"
+	}
+	w.notes[ref.Label] = len(w.notes) + 1
+
+	def, ok := w.defs[ref.Label]
+	if !ok {
+		return "\\footnote{DEFINITION NOT FOUND}"
+	}
+	return "\\footnote{" + strings.TrimSuffix(w.blocks(def.Children), "\n") + "}"
+}
+
+var latexEscaper = strings.NewReplacer(
+	"\\", "\\textbackslash{}", "{", "\\{", "}", "\\}", "$", "\\$", "&", "\\&",
+	"#", "\\#", "%", "\\%", "_", "\\_", "~", "\\textasciitilde{}", "^", "\\textasciicircum{}",
+)
+
+func escapeLaTeX(s string) string {
+	return latexEscaper.Replace(s)
+}
+
+// angleEscaper escapes the angle brackets of active timestamps, which the
+// default font encoding prints as other characters.
+var angleEscaper = strings.NewReplacer("<", "\\textless{}", ">", "\\textgreater{}")
+
+var urlEscaper = strings.NewReplacer("#", "\\#", "%", "\\%", "{", "\\{", "}", "\\}")
+
+// escapeURL escapes the characters that end or break the argument of \url and \href.
+func escapeURL(url string) string {
+	return urlEscaper.Replace(url)
+}
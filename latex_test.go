@@ -0,0 +1,86 @@
+package goorgeous
+
+import (
+	"testing"
+)
+
+func TestLaTeX(t *testing.T) {
+	testCases := map[string]testCase{
+		"headlines": {
+			"* TODO [#A] a /headline/ :a:b:\ntext\n** child\n*** grandchild\n**** four\n***** five\n****** six\n",
+			"\\section{\\textbf{TODO} \\framebox{\\#A} a \\emph{headline}\\hfill{}\\textsc{a:b}}\n\ntext\n\n\\subsection{child}\n\n\\subsubsection{grandchild}\n\n\\paragraph{four}\n\n\\subparagraph{five}\n\n\\subparagraph{six}\n",
+		},
+		"inline": {
+			"*b* /i/ _u_ +s+ =v_1= ~c~ 50% of $5 & #1 {x} a\\b ~ ^\n",
+			"\\textbf{b} \\emph{i} \\uline{u} \\sout{s} \\texttt{v\\_1} \\texttt{c} 50\\% of \\$5 \\& \\#1 \\{x\\} a\\textbackslash{}b \\textasciitilde{} \\textasciicircum{}\n",
+		},
+		"timestamps": {
+			"<2024-01-02 Tue> [2024-01-02 Tue 10:00-12:00]\n",
+			"\\textit{\\textless{}2024-01-02 Tue\\textgreater{}} \\textit{[2024-01-02 Tue 10:00-12:00]}\n",
+		},
+		"links-and-images": {
+			"[[https://example.com/a#b][the site]] [[https://example.com/50%]] [[file:img.png][a gopher]]\n",
+			"\\href{https://example.com/a\\#b}{the site} \\url{https://example.com/50\\%} \\includegraphics[width=.9\\linewidth]{img.png}\n",
+		},
+		"lists": {
+			"- a\n- b\n\n1. one\n2. [@5] five\n\n- term :: def\n",
+			"\\begin{itemize}\n\\item a\n\\item b\n\\end{itemize}\n\n\\begin{enumerate}\n\\item one\n\\setcounter{enumi}{4}\n\\item five\n\\end{enumerate}\n\n\\begin{description}\n\\item[{term}] def\n\\end{description}\n",
+		},
+		"nested-lists": {
+			"1. a\n   1. [@3] c\n   - x\n",
+			"\\begin{enumerate}\n\\item a\n\\begin{enumerate}\n\\setcounter{enumii}{2}\n\\item c\n\\item x\n\\end{enumerate}\n\\end{enumerate}\n",
+		},
+		"checkboxes": {
+			"- [X] done [1/2]\n- [ ] todo\n\n- [-] term :: def\n",
+			"\\begin{itemize}\n\\item[{$\\boxtimes$}] done \\texttt{[1/2]}\n\\item[{$\\square$}] todo\n\\end{itemize}\n\n\\begin{description}\n\\item[{term}] $\\boxminus$ def\n\\end{description}\n",
+		},
+		"table": {
+			"| a | b |\n|---+---|\n| 1 | 2 |\n",
+			"\\begin{center}\n\\begin{tabular}{rr}\na & b \\\\\n\\hline\n1 & 2 \\\\\n\\end{tabular}\n\\end{center}\n",
+		},
+		"table-cookies": {
+			"| / | <  |   >  |\n| <l> | <c> | <r10> |\n| a | b | c |\n|---+---+---|\n| 1 | 2 | 3 |\n",
+			"\\begin{center}\n\\begin{tabular}{l|cr}\na & b & c \\\\\n\\hline\n1 & 2 & 3 \\\\\n\\end{tabular}\n\\end{center}\n",
+		},
+		"blocks": {
+			"#+BEGIN_SRC go\nx := 1 % 2\n#+END_SRC\n#+BEGIN_QUOTE\nquoted\n#+END_QUOTE\n#+BEGIN_CENTER\ncentered\n#+END_CENTER\n#+BEGIN_EXAMPLE\n50%\n#+END_EXAMPLE\n#+BEGIN_VERSE\none & two\nthree\n#+END_VERSE\n#+BEGIN_EXPORT latex\n\\newpage\n#+END_EXPORT\n#+BEGIN_EXPORT html\n<br>\n#+END_EXPORT\n-----\n",
+			"\\begin{lstlisting}[language=go]\nx := 1 % 2\n\\end{lstlisting}\n\n\\begin{quote}\nquoted\n\\end{quote}\n\n\\begin{center}\ncentered\n\\end{center}\n\n\\begin{verbatim}\n50%\n\\end{verbatim}\n\n\\begin{verse}\none \\& two \\\\\nthree\n\\end{verse}\n\n\\newpage\n\n\\noindent\\rule{\\linewidth}{0.5pt}\n",
+		},
+		"footnotes": {
+			"a[fn:1] b[fn:1] c[fn:2]\n\n[fn:1] note /1/\n",
+			"a\\footnote{note \\emph{1}} b\\footnotemark[1] c\\footnote{DEFINITION NOT FOUND}\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := LaTeX([]byte(tc.in), LaTeXOptions{})
+		if string(out) != tc.expected {
+			t.Errorf("case %s for LaTeX() from %q = %q\nwants: %q", caseName, tc.in, out, tc.expected)
+		}
+	}
+}
+
+func TestLaTeXOptions(t *testing.T) {
+	in := "#+TITLE: A & B\n#+AUTHOR: me\n#+BEGIN_SRC\nx\n#+END_SRC\n"
+
+	testCases := map[string]struct {
+		opts     LaTeXOptions
+		expected string
+	}{
+		"minted": {
+			LaTeXOptions{Minted: true},
+			"\\begin{minted}{text}\nx\n\\end{minted}\n",
+		},
+		"standalone": {
+			LaTeXOptions{Standalone: true},
+			"\\documentclass{article}\n\\usepackage[utf8]{inputenc}\n\\usepackage{graphicx}\n\\usepackage{amssymb}\n\\usepackage[normalem]{ulem}\n\\usepackage{listings}\n\\usepackage{hyperref}\n\\begin{document}\n\n\\title{A \\& B}\n\\author{me}\n\\maketitle\n\n\\begin{lstlisting}\nx\n\\end{lstlisting}\n\n\\end{document}\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := LaTeX([]byte(in), tc.opts)
+		if string(out) != tc.expected {
+			t.Errorf("case %s for LaTeX() = %q\nwants: %q", caseName, out, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package goorgeous
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var reClock = regexp.MustCompile(`^\s*CLOCK:\s*`)
+var reClockDuration = regexp.MustCompile(`^\s*=>\s*(\d+):(\d{2})\s*$`)
+
+func isClock(data []byte) bool {
+	return reClock.Match(data)
+}
+
+// parseClock parses a CLOCK: line, returning nil if data isn't one.
+func parseClock(data []byte) *Clock {
+	rest := data[len(reClock.Find(data)):]
+	ts, n := parseTimestamp(rest)
+	if ts == nil || ts.Active || !ts.HasTime {
+		return nil
+	}
+	clock := &Clock{Timestamp: ts}
+	rest = rest[n:]
+	if ts.End.IsZero() {
+		if len(bytes.TrimSpace(rest)) > 0 {
+			return nil
+		}
+		return clock
+	}
+
+	clock.Duration = ts.End.Sub(ts.Time)
+	if m := reClockDuration.FindSubmatch(rest); m != nil {
+		hours, _ := strconv.Atoi(string(m[1]))
+		minutes, _ := strconv.Atoi(string(m[2]))
+		clock.Duration = time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	} else if len(bytes.TrimSpace(rest)) > 0 {
+		return nil
+	}
+	return clock
+}
+
+// clockString returns the org form of a clock line, without its trailing
+// newline.
+func clockString(c *Clock) string {
+	const layout = "[2006-01-02 Mon 15:04]"
+	text := "CLOCK: " + c.Timestamp.Time.Format(layout)
+	if !c.Timestamp.End.IsZero() {
+		text += "--" + c.Timestamp.End.Format(layout) + " => " + fmt.Sprintf("%5s", clockDuration(c.Duration))
+	}
+	return text
+}
+
+// clockDuration formats a duration the way clock lines do, e.g. 1:30.
+func clockDuration(d time.Duration) string {
+	minutes := int(d / time.Minute)
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
+
+// Clocks returns the CLOCK lines recorded for h, in its LOGBOOK or any other
+// drawer or directly in its section. The clocks of sub headlines aren't
+// included.
+func (h *Headline) Clocks() []*Clock {
+	var clocks []*Clock
+	for _, n := range headlineLog(h) {
+		Walk(n, func(n Node) bool {
+			if clock, ok := n.(*Clock); ok {
+				clocks = append(clocks, clock)
+			}
+			return true
+		})
+	}
+	return clocks
+}
+
+// StateChange is a change of TODO state logged for a headline, from a
+// LOGBOOK entry such as
+//
+//   - State "DONE"       from "TODO"       [2024-01-02 Tue 10:00] \\
+//     finally
+//
+// From is empty when the headline had no TODO keyword before. Note holds
+// the text after the timestamp.
+type StateChange struct {
+	State string
+	From  string
+	Time  *Timestamp
+	Note  string
+}
+
+var reStateChange = regexp.MustCompile(`(?s)^State\s+"([^"]*)"\s+from\s+(?:"([^"]*)")?\s*(\[[^\]]*\])\s*(?:\\\\)?\s*(.*)$`)
+
+// StateChanges returns the state changes logged for h, in its LOGBOOK
+// drawer or directly in its section, in the order they are written, which
+// org makes newest first.
+func (h *Headline) StateChanges() []StateChange {
+	var changes []StateChange
+	for _, n := range headlineLog(h) {
+		Walk(n, func(n Node) bool {
+			switch n := n.(type) {
+			case *Drawer:
+				return strings.EqualFold(n.Name, "LOGBOOK")
+			case *ListItem:
+				if change, ok := parseStateChange(n); ok {
+					changes = append(changes, change)
+				}
+				return false
+			}
+			return true
+		})
+	}
+	return changes
+}
+
+func parseStateChange(item *ListItem) (StateChange, bool) {
+	var paragraphs []string
+	for _, n := range item.Children {
+		if para, ok := n.(*Paragraph); ok {
+			paragraphs = append(paragraphs, orgString(para.Children))
+		}
+	}
+	m := reStateChange.FindStringSubmatch(strings.Join(paragraphs, "\n"))
+	if m == nil {
+		return StateChange{}, false
+	}
+	ts, _ := parseTimestamp([]byte(m[3]))
+	if ts == nil {
+		return StateChange{}, false
+	}
+
+	var note []string
+	for _, line := range strings.Split(m[4], "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			note = append(note, line)
+		}
+	}
+	return StateChange{State: m[1], From: m[2], Time: ts, Note: strings.Join(note, "\n")}, true
+}
+
+// headlineLog returns the elements of the section of h, where org logs
+// clocks and state changes.
+func headlineLog(h *Headline) []Node {
+	if len(h.Children) > 0 {
+		if section, ok := h.Children[0].(*Section); ok {
+			return section.Children
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package goorgeous
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHeadlineLog(t *testing.T) {
+	in := "* DONE task\n" +
+		":LOGBOOK:\n" +
+		"- State \"DONE\"       from \"NEXT\"       [2024-01-03 Wed 17:00] \\\\\n" +
+		"  shipped it,\n" +
+		"  finally\n" +
+		"- State \"NEXT\"       from              [2024-01-02 Tue 9:00]\n" +
+		"- Note taken on [2024-01-02 Tue 9:05]\n" +
+		"CLOCK: [2024-01-03 Wed 13:00]--[2024-01-03 Wed 17:00] =>  4:00\n" +
+		"CLOCK: [2024-01-02 Tue 9:00]--[2024-01-02 Tue 9:45]\n" +
+		":END:\n" +
+		"CLOCK: [2024-01-04 Thu 8:00]\n" +
+		"** sub\n" +
+		"CLOCK: [2024-01-05 Fri 8:00]--[2024-01-05 Fri 9:00] =>  1:00\n"
+	doc, _ := Parse([]byte(in))
+	h := doc.Children[0].(*Headline)
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+	}
+
+	var durations []time.Duration
+	var starts []time.Time
+	for _, clock := range h.Clocks() {
+		durations = append(durations, clock.Duration)
+		starts = append(starts, clock.Timestamp.Time)
+	}
+	expectedDurations := []time.Duration{4 * time.Hour, 45 * time.Minute, 0}
+	expectedStarts := []time.Time{at(3, 13, 0), at(2, 9, 0), at(4, 8, 0)}
+	if !reflect.DeepEqual(durations, expectedDurations) || !reflect.DeepEqual(starts, expectedStarts) {
+		t.Errorf("Clocks() = %v at %v\nwants: %v at %v", durations, starts, expectedDurations, expectedStarts)
+	}
+
+	changes := h.StateChanges()
+	expected := []StateChange{
+		{State: "DONE", From: "NEXT", Time: &Timestamp{Time: at(3, 17, 0), HasTime: true}, Note: "shipped it,\nfinally"},
+		{State: "NEXT", Time: &Timestamp{Time: at(2, 9, 0), HasTime: true}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("StateChanges() = %+v\nwants: %+v", changes, expected)
+	}
+}
@@ -0,0 +1,341 @@
+package goorgeous
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownRenderer renders a Document as CommonMark with the GitHub Flavored
+// Markdown extensions for tables, strike-through and footnotes.
+//
+// Constructs Markdown has no syntax for are simplified: tags, planning lines,
+// drawers, keywords and comments are dropped, underlined text is written as
+// plain text and descriptive lists become lists with a bold term.
+type MarkdownRenderer struct{}
+
+// NewMarkdownRenderer returns a MarkdownRenderer.
+func NewMarkdownRenderer() *MarkdownRenderer {
+	return &MarkdownRenderer{}
+}
+
+// Markdown is the easiest way to turn a byte slice of org content into Markdown.
+func Markdown(input []byte) []byte {
+	var out bytes.Buffer
+	doc, _ := Parse(input)
+	NewMarkdownRenderer().Render(&out, doc)
+	return out.Bytes()
+}
+
+// Render writes doc to w as Markdown.
+func (r *MarkdownRenderer) Render(w io.Writer, doc *Document) error {
+	mw := &markdownWriter{
+		defs:  make(map[string]*FootnoteDefinition),
+		notes: make(map[string]bool),
+	}
+	Walk(doc, func(n Node) bool {
+		if def, ok := n.(*FootnoteDefinition); ok {
+			mw.defs[def.Label] = def
+		}
+		return true
+	})
+
+	blocks := []string{mw.blocks(doc.Children)}
+	// definitions can reference further footnotes, which extend mw.order
+	for i := 0; i < len(mw.order); i++ {
+		if def, ok := mw.defs[mw.order[i]]; ok {
+			label := "[^" + mw.order[i] + "]: "
+			blocks = append(blocks, indentBlock(label, "    ", mw.blocks(def.Children)))
+		}
+	}
+
+	_, err := io.WriteString(w, joinBlocks(blocks))
+	return err
+}
+
+// markdownWriter holds the state of a single Render call.
+type markdownWriter struct {
+	defs map[string]*FootnoteDefinition
+	// notes holds the referenced footnotes, order them in the order they're
+	// first referenced
+	notes map[string]bool
+	order []string
+}
+
+// blocks returns the Markdown for a run of elements, separated by blank lines.
+func (w *markdownWriter) blocks(nodes []Node) string {
+	var blocks []string
+	for _, n := range nodes {
+		if block := w.element(n); block != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	return joinBlocks(blocks)
+}
+
+func (w *markdownWriter) element(n Node) string {
+	switch n := n.(type) {
+	case *Section:
+		return w.blocks(n.Children)
+	case *Headline:
+		return joinBlocks([]string{w.headline(n), w.blocks(n.Children)})
+	case *Paragraph:
+		return escapeBlockStarts(w.inline(n.Children)) + "\n"
+	case *List:
+		return w.list(n)
+	case *Table:
+		return w.table(n)
+	case *Block:
+		return w.block(n)
+	case *FixedWidth:
+		return fence("", strings.Join(n.Lines, "\n")+"\n")
+	case *HorizontalRule:
+		return "---\n"
+	}
+	return ""
+}
+
+func (w *markdownWriter) headline(h *Headline) string {
+	level := h.Level
+	if level > 6 {
+		level = 6
+	}
+	line := strings.Repeat("#", level) + " "
+	if h.Keyword != "" {
+		line += h.Keyword + " "
+	}
+	if h.Priority != nil {
+		line += "\\[#" + h.Priority.Value + "\\] "
+	}
+	return line + w.inline(h.Title) + "\n"
+}
+
+func (w *markdownWriter) list(l *List) string {
+	var out bytes.Buffer
+	number := 0
+	for _, item := range l.Items {
+		marker := "- "
+		if l.Kind == OrderedList {
+			number++
+			if n, err := strconv.Atoi(item.Counter); err == nil {
+				number = n
+			}
+			marker = strconv.Itoa(number) + ". "
+		}
+
+		body := w.blocks(item.Children)
+		// a sublist follows the bullet line directly, keeping the list tight
+		if first, rest := listItemBody(item); len(rest) == 1 && isList(rest[0]) {
+			body = w.blocks(first) + w.blocks(rest)
+		}
+		if l.Kind == DescriptiveList {
+			body = "**" + w.inline(item.Term) + "**: " + body
+		}
+		// task list items only know checked and unchecked boxes
+		switch item.Checkbox {
+		case "X":
+			body = "[x] " + body
+		case " ", "-":
+			body = "[ ] " + body
+		}
+		out.WriteString(indentBlock(marker, strings.Repeat(" ", len(marker)), body))
+	}
+	return out.String()
+}
+
+func (w *markdownWriter) table(t *Table) string {
+	columns := tableColumns(t)
+	header := make([]string, columns)
+
+	// Markdown tables have a single header row and body, so any further
+	// header rows go on top of the merged bodies.
+	var rows []*TableRow
+	if h := t.Header(); len(h) > 0 {
+		header = w.tableCells(h[0], columns)
+		rows = h[1:]
+	}
+	for _, body := range t.Bodies() {
+		rows = append(rows, body...)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	out.WriteString("|")
+	for _, column := range t.Columns() {
+		out.WriteString(map[ColumnAlignment]string{AlignLeft: " --- |", AlignRight: " ---: |", AlignCenter: " :---: |"}[column.Align])
+	}
+	out.WriteString("\n")
+	for _, row := range rows {
+		out.WriteString("| " + strings.Join(w.tableCells(row, columns), " | ") + " |\n")
+	}
+	return out.String()
+}
+
+// tableCells returns the Markdown for the cells of a row, padded to columns.
+func (w *markdownWriter) tableCells(row *TableRow, columns int) []string {
+	cells := make([]string, columns)
+	for i, cell := range row.Cells {
+		cells[i] = strings.Replace(w.inline(cell.Children), "|", "\\|", -1)
+	}
+	return cells
+}
+
+func (w *markdownWriter) block(b *Block) string {
+	name := strings.ToUpper(b.Name)
+	lines := strings.Join(b.Lines, "\n")
+	if len(b.Lines) > 0 {
+		lines += "\n"
+	}
+	format := ""
+	if len(b.Parameters) > 0 {
+		format = strings.ToLower(b.Parameters[0])
+	}
+
+	switch {
+	case name == "QUOTE":
+		return indentBlock("> ", "> ", w.blocks(b.Children))
+	case name == "CENTER":
+		return w.blocks(b.Children)
+	case name == "SRC":
+		return fence(format, lines)
+	case name == "EXPORT" && (format == "md" || format == "markdown" || format == "html"), name == "HTML":
+		return lines
+	case name == "EXPORT", name == "COMMENT":
+		return ""
+	case name == "VERSE":
+		return strings.Replace(strings.TrimSuffix(escapeMarkdown(lines), "\n"), "\n", "\\\n", -1) + "\n"
+	}
+	return fence("", lines)
+}
+
+func (w *markdownWriter) inline(nodes []Node) string {
+	var out bytes.Buffer
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Text:
+			out.WriteString(escapeMarkdown(n.Value))
+		case *Code:
+			out.WriteString(codeSpan(n.Value))
+		case *Emphasis:
+			marker := markdownEmphasis[n.Kind]
+			out.WriteString(marker + w.inline(n.Children) + marker)
+		case *Link:
+			out.WriteString(w.link(n))
+		case *FootnoteReference:
+			if !w.notes[n.Label] {
+				w.notes[n.Label] = true
+				w.order = append(w.order, n.Label)
+			}
+			out.WriteString("[^" + n.Label + "]")
+		case *StatisticsCookie:
+			out.WriteString(escapeMarkdown("[" + n.Value + "]"))
+		case *Timestamp:
+			out.WriteString(escapeMarkdown(timestampString(n)))
+		}
+	}
+	return out.String()
+}
+
+// markdownEmphasis holds the markers for each kind of emphasis. Markdown has
+// no underline, so underlined text is written without markers.
+var markdownEmphasis = map[EmphasisKind]string{
+	Bold:          "**",
+	Italic:        "*",
+	StrikeThrough: "~~",
+}
+
+func (w *markdownWriter) link(l *Link) string {
+	url, isImage := linkTarget(l)
+	destination := "(" + destinationEscaper.Replace(url) + ")"
+	if isImage {
+		alt := url
+		if len(l.Description) > 0 {
+			alt = orgString(l.Description)
+		}
+		return "![" + escapeMarkdown(alt) + "]" + destination
+	}
+	if len(l.Description) > 0 {
+		return "[" + w.inline(l.Description) + "]" + destination
+	}
+	if strings.Contains(url, "://") && !strings.ContainsAny(url, " <>") {
+		return "<" + url + ">"
+	}
+	return "[" + escapeMarkdown(url) + "]" + destination
+}
+
+var destinationEscaper = strings.NewReplacer("(", "\\(", ")", "\\)", " ", "%20")
+
+// codeSpan wraps code in enough backticks that none inside it end the span.
+func codeSpan(code string) string {
+	ticks := "`"
+	for strings.Contains(code, ticks) {
+		ticks += "`"
+	}
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return ticks + code + ticks
+}
+
+// fence returns a fenced code block for lines, with a fence longer than any
+// run of backticks in them.
+func fence(info, lines string) string {
+	ticks := "```"
+	for strings.Contains(lines, ticks) {
+		ticks += "`"
+	}
+	return ticks + info + "\n" + lines + ticks + "\n"
+}
+
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "~", "\\~",
+	"[", "\\[", "]", "\\]", "<", "\\<",
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+var reMarkdownBlockStart = regexp.MustCompile(`(?m)^([ \t]*)([#>+=-]|\d+[.)])`)
+
+// escapeBlockStarts escapes the characters at the start of a paragraph's
+// lines that would make Markdown read them as headings, quotes or lists.
+func escapeBlockStarts(text string) string {
+	return reMarkdownBlockStart.ReplaceAllStringFunc(text, func(start string) string {
+		last := len(start) - 1
+		return start[:last] + "\\" + start[last:]
+	})
+}
+
+// indentBlock prefixes the first line of text with first and every other
+// non-blank line with rest.
+func indentBlock(first, rest, text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = first + line
+		case line == "":
+			lines[i] = strings.TrimRight(rest, " ")
+		default:
+			lines[i] = rest + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// joinBlocks joins non-empty blocks with blank lines.
+func joinBlocks(blocks []string) string {
+	var nonEmpty []string
+	for _, block := range blocks {
+		if block != "" {
+			nonEmpty = append(nonEmpty, strings.TrimSuffix(block, "\n"))
+		}
+	}
+	if len(nonEmpty) == 0 {
+		return ""
+	}
+	return strings.Join(nonEmpty, "\n\n") + "\n"
+}
@@ -0,0 +1,73 @@
+package goorgeous
+
+import (
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	testCases := map[string]testCase{
+		"headlines": {
+			"* TODO [#A] a /headline/ :tag:\ntext\n** child\n",
+			"# TODO \\[#A\\] a *headline*\n\ntext\n\n## child\n",
+		},
+		"inline": {
+			"*b* /i/ _u_ +s+ =v= ~c~ =a`b= 2*3\n",
+			"**b** *i* u ~~s~~ `v` `c` ``a`b`` 2\\*3\n",
+		},
+		"block-starts": {
+			"text\n#hashtag\n+plus\n> quote\n--- x\n  2024) year\n",
+			"text\n\\#hashtag\n\\+plus\n\\> quote\n\\--- x\n  2024\\) year\n",
+		},
+		"links-and-images": {
+			"[[https://example.com][the site]] [[https://example.com]] [[./a post.org]] [[file:img.png][a gopher]]\n",
+			"[the site](https://example.com) <https://example.com> [/a post](/a%20post) ![a gopher](img.png)\n",
+		},
+		"lists": {
+			"- a\n- b\n\n1. one\n2. [@5] five\n3. six\n\n- term :: def\n",
+			"- a\n- b\n\n1. one\n5. five\n6. six\n\n- **term**: def\n",
+		},
+		"nested-lists": {
+			"- a\n  1. one\n  2. two\n     - term :: def\n- b\n",
+			"- a\n  1. one\n  2. two\n     - **term**: def\n- b\n",
+		},
+		"checkboxes": {
+			"- [X] done [1/2]\n- [-] partial\n",
+			"- [x] done \\[1/2\\]\n- [ ] partial\n",
+		},
+		"table": {
+			"| a | b |\n|---+---|\n| 1 | x\\vert{}y |\n| 3 |\n",
+			"| a | b |\n| ---: | --- |\n| 1 | x\\\\vert{}y |\n| 3 |  |\n",
+		},
+		"table-without-header": {
+			"| 1 | 2 |\n",
+			"|  |  |\n| ---: | ---: |\n| 1 | 2 |\n",
+		},
+		"table-cookies": {
+			"| / | <  |   >  |\n| <l> | <c> | <r10> |\n| a | b | c |\n|---+---+---|\n| 1 | 2 | 3 |\n",
+			"| a | b | c |\n| --- | :---: | ---: |\n| 1 | 2 | 3 |\n",
+		},
+		"table-row-groups": {
+			"| a | b |\n| c | d |\n|---+---|\n| e | f |\n|---+---|\n|---+---|\n| g | h |\n| i | j |\n|---+---|\n",
+			"| a | b |\n| --- | --- |\n| c | d |\n| e | f |\n| g | h |\n| i | j |\n",
+		},
+		"blocks": {
+			"#+BEGIN_SRC go\nfmt.Println(\"```\")\n#+END_SRC\n#+BEGIN_QUOTE\nquoted\n\ntext\n#+END_QUOTE\n#+BEGIN_EXAMPLE\nexample\n#+END_EXAMPLE\n#+BEGIN_VERSE\nline *one*\nline two\n#+END_VERSE\n#+BEGIN_EXPORT latex\n\\LaTeX\n#+END_EXPORT\n: fixed\n-----\n",
+			"````go\nfmt.Println(\"```\")\n````\n\n> quoted\n>\n> text\n\n```\nexample\n```\n\nline \\*one\\*\\\nline two\n\n```\nfixed\n```\n\n---\n",
+		},
+		"footnotes": {
+			"a[fn:1] b[fn:x] c[fn:1]\n\n[fn:x] note /x/\n\n[fn:1] note 1\n",
+			"a[^1] b[^x] c[^1]\n\n[^1]: note 1\n\n[^x]: note *x*\n",
+		},
+		"dropped": {
+			"#+TITLE: title\n# comment\n:PROPERTIES:\n:ID: 1\n:END:\ntext\n",
+			"text\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := Markdown([]byte(tc.in))
+		if string(out) != tc.expected {
+			t.Errorf("case %s for Markdown() from %q = %q\nwants: %q", caseName, tc.in, out, tc.expected)
+		}
+	}
+}
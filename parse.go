@@ -0,0 +1,897 @@
+package goorgeous
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// ParseOptions configures ParseWithOptions.
+type ParseOptions struct {
+	// TodoKeywords holds the TODO keyword sequences of documents that don't
+	// set their own with #+TODO, #+SEQ_TODO or #+TYP_TODO lines. Sequences are
+	// written the way those lines are, e.g. "TODO NEXT | DONE CANCELLED", with
+	// the done states after the bar, or the last keyword being the done state
+	// when there is no bar. Without any sequences TODO and DONE are used.
+	TodoKeywords []string
+
+	// Priorities is the priority range of documents without a #+PRIORITIES
+	// line, written the way that line is: the highest, lowest and default
+	// priority, e.g. "A E C" or "1 10 5". Without it A to C is used.
+	Priorities string
+
+	// RecalculateTables evaluates the #+TBLFM formulas of tables, so they hold
+	// computed values instead of the ones they were saved with; Write writes
+	// the computed values too. Formulas that fail leave #ERROR in their fields.
+	RecalculateTables bool
+
+	// UpdateStatistics computes the statistics cookies and the checkboxes of
+	// list items with sub items, see Document.UpdateStatistics, so renderers
+	// and Write show the computed values instead of the ones in the input.
+	UpdateStatistics bool
+}
+
+// Parse parses a byte slice of org content into a Document tree. The tree can
+// be inspected or modified and then rendered, or written back out with Write.
+// Parsing a byte slice never fails; the error is reserved for input that
+// cannot be read.
+func Parse(input []byte) (*Document, error) {
+	return ParseWithOptions(input, ParseOptions{})
+}
+
+// ParseWithOptions is Parse with options.
+func ParseWithOptions(input []byte, opts ParseOptions) (*Document, error) {
+	p := NewParser(nil)
+	p.input = input
+	p.src = make(map[Node]*source)
+
+	var lines [][]byte
+	for start := 0; start < len(input); {
+		end, next := len(input), len(input)
+		if i := bytes.IndexByte(input[start:], '\n'); i >= 0 {
+			end, next = start+i, start+i+1
+		}
+		lines = append(lines, bytes.TrimSuffix(input[start:end], []byte("\r")))
+		p.lineStart = append(p.lineStart, start)
+		start = next
+	}
+	p.lineStart = append(p.lineStart, len(input))
+	p.todo = todoKeywords(lines, opts.TodoKeywords)
+	p.priorities = priorities(lines, opts.Priorities)
+
+	doc := p.parseDocument(lines)
+	prints := make(map[Node]string)
+	for n, s := range p.src {
+		s.fingerprint(n, prints)
+	}
+	doc.src = p.src
+
+	if opts.RecalculateTables {
+		doc.RecalculateTables()
+	}
+	if opts.UpdateStatistics {
+		doc.UpdateStatistics()
+	}
+	return doc, nil
+}
+
+func (p *parser) parseDocument(lines [][]byte) *Document {
+	doc := new(Document)
+	var stack []*Headline
+
+	add := func(n Node) {
+		if len(stack) == 0 {
+			doc.Children = append(doc.Children, n)
+			return
+		}
+		parent := stack[len(stack)-1]
+		parent.Children = append(parent.Children, n)
+	}
+
+	// starts holds the first line of each headline on the stack, so the
+	// source of a headline can be recorded once its subtree is complete
+	var starts []int
+	pop := func(end int) {
+		p.record(stack[len(stack)-1], starts[len(starts)-1], end)
+		stack, starts = stack[:len(stack)-1], starts[:len(starts)-1]
+	}
+
+	i := skipBlank(lines, 0, len(lines))
+	p.record(doc, 0, len(lines))
+	p.src[doc].head = p.text(0, i)
+
+	for i < len(lines) {
+		start := i
+		var elements []Node
+		elements, i = p.parseElements(lines, i, len(lines), isHeadline)
+		if len(elements) > 0 {
+			section := &Section{Children: elements}
+			p.record(section, start, i)
+			add(section)
+		}
+		if i >= len(lines) {
+			break
+		}
+
+		headline := p.parseHeadline(lines[i])
+		for len(stack) > 0 && stack[len(stack)-1].Level >= headline.Level {
+			pop(i)
+		}
+		add(headline)
+		stack, starts = append(stack, headline), append(starts, i)
+
+		s := new(source)
+		start, i = i, i+1
+		if i < len(lines) {
+			if planning := parsePlanning(lines[i]); planning != nil {
+				headline.Planning = planning
+				s.planning = p.text(i, i+1)
+				i++
+			}
+		}
+		if i < len(lines) && isPropertyDrawer(lines[i]) {
+			if properties, next := p.parseProperties(lines, i); len(properties) > 0 {
+				headline.Properties = properties
+				s.properties = p.text(i, next)
+				i = next
+			}
+		}
+		i = skipBlank(lines, i, len(lines))
+		s.head = p.text(start, i)
+		p.src[headline] = s
+	}
+	for len(stack) > 0 {
+		pop(len(lines))
+	}
+
+	return doc
+}
+
+// parseElements parses the elements starting at lines[i] until it reaches
+// lines[limit] or a line that stop reports true for. It returns the elements
+// and the index of the first line it did not consume.
+func (p *parser) parseElements(lines [][]byte, i, limit int, stop func([]byte) bool) ([]Node, int) {
+	var nodes []Node
+	for i < limit {
+		if stop != nil && stop(lines[i]) {
+			break
+		}
+		start := i
+		// affiliated keywords belong to the element below them
+		var affiliated *Affiliated
+		if j := affiliatedEnd(lines, i, limit); j > i && j < limit && takesAffiliated(lines[j]) && (stop == nil || !stop(lines[j])) {
+			affiliated, i = p.parseAffiliated(lines[i:j]), j
+		}
+		elementStart := i
+		var n Node
+		n, i = p.parseElement(lines[:limit], i)
+		i = skipBlank(lines, i, limit)
+		p.record(n, start, i)
+		if affiliated != nil {
+			setAffiliated(n, affiliated)
+			p.src[n].affiliated = p.text(start, elementStart)
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, i
+}
+
+// record remembers lines[start:end] as the source of n.
+func (p *parser) record(n Node, start, end int) {
+	s, ok := p.src[n]
+	if !ok {
+		s = new(source)
+		p.src[n] = s
+	}
+	s.raw = p.text(start, end)
+}
+
+// text returns the input lines[start:end] were taken from, including
+// their line endings.
+func (p *parser) text(start, end int) string {
+	return string(p.input[p.lineStart[p.lineOffset+start]:p.lineStart[p.lineOffset+end]])
+}
+
+func skipBlank(lines [][]byte, i, limit int) int {
+	for i < limit && isEmpty(lines[i]) {
+		i++
+	}
+	return i
+}
+
+func (p *parser) parseElement(lines [][]byte, i int) (Node, int) {
+	data := lines[i]
+	switch {
+	case isDrawer(data):
+		if n, next := p.parseDrawer(lines, i); n != nil {
+			return n, next
+		}
+	case isClock(data):
+		if n := parseClock(data); n != nil {
+			return n, i + 1
+		}
+	case isBlock(data):
+		if n, next := p.parseBlock(lines, i); n != nil {
+			return n, next
+		}
+	case isFootnoteDef(data):
+		return p.parseFootnoteDef(lines, i)
+	case isTable(data):
+		return p.parseTable(lines, i)
+	case IsKeyword(data):
+		return parseKeyword(data), i + 1
+	case isComment(data):
+		return &Comment{Value: string(data[2:])}, i + 1
+	case isListItem(data):
+		return p.parseList(lines, i)
+	case isHorizontalRule(data):
+		return &HorizontalRule{}, i + 1
+	case isExampleLine(data):
+		return parseFixedWidth(lines, i)
+	}
+	return p.parseParagraph(lines, i)
+}
+
+// startsElement reports whether data is the first line of anything other than
+// a paragraph, which ends a paragraph that is still collecting lines.
+func startsElement(data []byte) bool {
+	return isHeadline(data) || isDrawer(data) || isClock(data) || isBlock(data) || isFootnoteDef(data) ||
+		isTable(data) || IsKeyword(data) || isComment(data) || isListItem(data) ||
+		isHorizontalRule(data) || isExampleLine(data)
+}
+
+// Headlines
+func (p *parser) parseHeadline(data []byte) *Headline {
+	level := 0
+	for level < len(data) && charMatches(data[level], '*') {
+		level++
+	}
+	data = data[skipChar(data, level, ' '):]
+
+	headline := &Headline{Level: level}
+	i := 0
+
+	word := len(data)
+	if end := bytes.IndexByte(data, ' '); end >= 0 {
+		word = end
+	}
+	if done, ok := p.todo[string(data[:word])]; ok {
+		headline.Keyword, headline.Done = string(data[:word]), done
+		i = skipChar(data, word, ' ')
+	}
+
+	if bytes.HasPrefix(data[i:], []byte("[#")) {
+		if end := bytes.IndexByte(data[i:], ']'); end > 2 {
+			value := string(data[i+2 : i+end])
+			if rank, ok := p.priorities.rank(value); ok {
+				headline.Priority = &Priority{Value: value, Rank: rank}
+				i = skipChar(data, i+end+1, ' ')
+			}
+		}
+	}
+
+	tags, tagsFound := findTags(data, i)
+	dataEnd := len(data)
+	if tagsFound > 0 {
+		dataEnd = tagsFound
+		headline.Tags = tags
+	}
+
+	headline.Title = p.inline(bytes.TrimRight(data[i:dataEnd], " \t"))
+	return headline
+}
+
+// todoKeywords returns the TODO keywords set by the #+TODO, #+SEQ_TODO and
+// #+TYP_TODO lines in lines, or by defaults when there are none, mapped to
+// whether they are done states.
+func todoKeywords(lines [][]byte, defaults []string) map[string]bool {
+	var sequences []string
+	for _, line := range lines {
+		if !IsKeyword(line) {
+			continue
+		}
+		switch k := parseKeyword(line); strings.ToUpper(k.Key) {
+		case "TODO", "SEQ_TODO", "TYP_TODO":
+			sequences = append(sequences, k.Value)
+		}
+	}
+	if len(sequences) == 0 {
+		sequences = defaults
+	}
+	if len(sequences) == 0 {
+		sequences = []string{"TODO | DONE"}
+	}
+
+	todo := make(map[string]bool)
+	for _, sequence := range sequences {
+		keywords := strings.Fields(sequence)
+		done := len(keywords) - 1
+		for i, keyword := range keywords {
+			if keyword == "|" {
+				done = i
+				break
+			}
+		}
+		for i, keyword := range keywords {
+			if keyword == "|" {
+				continue
+			}
+			// drop fast access keys and logging settings such as WAIT(w@/!)
+			if j := strings.IndexByte(keyword, '('); j > 0 {
+				keyword = keyword[:j]
+			}
+			todo[keyword] = i >= done
+		}
+	}
+	return todo
+}
+
+// priorityRange is the range of priority cookies a document accepts, from
+// highest to lowest. Letters are held as their character codes.
+type priorityRange struct {
+	numeric         bool
+	highest, lowest int
+}
+
+// priorities returns the priority range set by the #+PRIORITIES line in
+// lines, or by defaults when there is none or it isn't valid.
+func priorities(lines [][]byte, defaults string) priorityRange {
+	var values []string
+	for _, line := range lines {
+		if IsKeyword(line) {
+			if k := parseKeyword(line); strings.ToUpper(k.Key) == "PRIORITIES" {
+				values = append(values, k.Value)
+			}
+		}
+	}
+
+	for _, value := range append(values, defaults) {
+		if r, ok := parsePriorityRange(value); ok {
+			return r
+		}
+	}
+	return priorityRange{highest: 'A', lowest: 'C'}
+}
+
+// parsePriorityRange parses the highest and lowest priority from a
+// #+PRIORITIES value. Org allows numbers from 0 to 64 or capital letters.
+func parsePriorityRange(value string) (priorityRange, bool) {
+	fields := strings.Fields(value)
+	if len(fields) < 2 {
+		return priorityRange{}, false
+	}
+
+	if highest, err := strconv.Atoi(fields[0]); err == nil {
+		lowest, err := strconv.Atoi(fields[1])
+		if err != nil || highest < 0 || lowest > 64 || highest > lowest {
+			return priorityRange{}, false
+		}
+		return priorityRange{numeric: true, highest: highest, lowest: lowest}, true
+	}
+
+	if !isPriorityLetter(fields[0]) || !isPriorityLetter(fields[1]) || fields[0] > fields[1] {
+		return priorityRange{}, false
+	}
+	return priorityRange{highest: int(fields[0][0]), lowest: int(fields[1][0])}, true
+}
+
+func isPriorityLetter(s string) bool {
+	return len(s) == 1 && s[0] >= 'A' && s[0] <= 'Z'
+}
+
+// rank returns how far the priority cookie value is from the highest
+// priority, reporting false when it is outside the range.
+func (r priorityRange) rank(value string) (int, bool) {
+	n := -1
+	if r.numeric {
+		if v, err := strconv.Atoi(value); err == nil {
+			n = v
+		}
+	} else if isPriorityLetter(value) {
+		n = int(value[0])
+	}
+	if n < r.highest || n > r.lowest {
+		return 0, false
+	}
+	return n - r.highest, true
+}
+
+// Greater Elements
+// parseDrawer parses the drawer starting at lines[i], returning nil if it
+// isn't closed before the next headline.
+func (p *parser) parseDrawer(lines [][]byte, i int) (Node, int) {
+	name := string(bytes.Trim(bytes.TrimSpace(lines[i]), ":"))
+	for end := i + 1; end < len(lines) && !isHeadline(lines[end]); end++ {
+		if !bytes.EqualFold(bytes.TrimSpace(lines[end]), []byte(":END:")) {
+			continue
+		}
+		drawer := &Drawer{Name: name}
+		contentStart := i + 1
+		if strings.EqualFold(name, "PROPERTIES") {
+			for _, line := range lines[i+1 : end] {
+				drawer.Lines = append(drawer.Lines, string(line))
+			}
+		} else {
+			contentStart = skipBlank(lines, contentStart, end)
+			drawer.Children, _ = p.parseElements(lines, contentStart, end, nil)
+		}
+		p.src[drawer] = &source{head: p.text(i, contentStart), tail: p.text(end, end+1)}
+		return drawer, end + 1
+	}
+	return nil, i
+}
+
+// parseProperties parses the property drawer starting at lines[i]. A KEY+
+// property extends the value of an earlier KEY in the drawer; without one it
+// is kept as KEY+ to extend an inherited value, see InheritedProperty.
+func (p *parser) parseProperties(lines [][]byte, i int) (map[string]string, int) {
+	n, next := p.parseDrawer(lines, i)
+	if n == nil {
+		return nil, i
+	}
+	// the drawer itself isn't part of the tree
+	delete(p.src, n)
+
+	properties := make(map[string]string)
+	for _, line := range n.(*Drawer).Lines {
+		key, value, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		if strings.HasSuffix(key, "+") {
+			for _, k := range []string{strings.TrimSuffix(key, "+"), key} {
+				if name, ok := propertyKey(properties, k); ok {
+					key, value = name, joinPropertyValues(properties[name], value)
+					break
+				}
+			}
+		}
+		properties[key] = value
+	}
+	return properties, next
+}
+
+// parseProperty parses a :KEY: value line of a property drawer.
+func parseProperty(line string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, ":") {
+		return "", "", false
+	}
+	end := strings.IndexByte(line[1:], ':') + 1
+	if end < 2 || strings.ContainsAny(line[1:end], " \t") {
+		return "", "", false
+	}
+	return line[1:end], strings.TrimSpace(line[end+1:]), true
+}
+
+func (p *parser) parseBlock(lines [][]byte, i int) (Node, int) {
+	matches := reBlock.FindSubmatch(lines[i])
+	if string(matches[1]) != "BEGIN" {
+		return nil, i
+	}
+	name := string(matches[2])
+
+	for end := i + 1; end < len(lines); end++ {
+		endMatches := reBlock.FindSubmatch(lines[end])
+		if endMatches == nil || string(endMatches[1]) != "END" || !strings.EqualFold(string(endMatches[2]), name) {
+			continue
+		}
+
+		block := &Block{Name: name}
+		if params := strings.Fields(string(lines[i]))[1:]; len(params) > 0 {
+			block.Parameters = params
+		}
+		contentStart := i + 1
+		if isGreaterBlock(name) {
+			contentStart = skipBlank(lines, contentStart, end)
+			block.Children, _ = p.parseElements(lines, contentStart, end, nil)
+		} else {
+			for _, line := range lines[i+1 : end] {
+				block.Lines = append(block.Lines, string(line))
+			}
+		}
+		p.src[block] = &source{head: p.text(i, contentStart), tail: p.text(end, end+1)}
+		return block, end + 1
+	}
+	return nil, i
+}
+
+// isGreaterBlock reports whether a block holds elements rather than verbatim text.
+func isGreaterBlock(name string) bool {
+	switch strings.ToUpper(name) {
+	case "QUOTE", "CENTER":
+		return true
+	}
+	return false
+}
+
+func (p *parser) parseFootnoteDef(lines [][]byte, i int) (Node, int) {
+	matches := reFootnoteDef.FindSubmatch(lines[i])
+	text := [][]byte{matches[2]}
+	for i++; i < len(lines) && !isEmpty(lines[i]) && !startsElement(lines[i]); i++ {
+		text = append(text, lines[i])
+	}
+	return &FootnoteDefinition{Label: string(matches[1]), Children: []Node{p.paragraph(text)}}, i
+}
+
+func (p *parser) parseTable(lines [][]byte, i int) (Node, int) {
+	table := new(Table)
+	for ; i < len(lines) && isTable(lines[i]); i++ {
+		row := p.parseTableRow(lines[i])
+		p.record(row, i, i+1)
+		table.Rows = append(table.Rows, row)
+	}
+	end := i
+	for ; i < len(lines) && isTableFormula(lines[i]); i++ {
+		table.Formulas = append(table.Formulas, parseKeyword(lines[i]).Value)
+	}
+	if i > end {
+		p.src[table] = &source{tail: p.text(end, i)}
+	}
+	return table, i
+}
+
+func (p *parser) parseTableRow(data []byte) *TableRow {
+	if reTableHeaders.Match(data) {
+		return &TableRow{Rule: true}
+	}
+
+	row := new(TableRow)
+	data = bytes.TrimRight(data[1:], " \t")
+	if len(data) > 0 && charMatches(data[len(data)-1], '|') {
+		data = data[:len(data)-1]
+	}
+	for _, cell := range bytes.Split(data, []byte("|")) {
+		row.Cells = append(row.Cells, &TableCell{Children: p.inline(bytes.Trim(cell, " \t"))})
+	}
+	return row
+}
+
+func isListItem(data []byte) bool {
+	return isDefinitionList(data) || isUnorderedList(data) || isOrderedList(data)
+}
+
+// parseList parses the items indented like the one at lines[i]. An item
+// runs on over the lines indented deeper than its bullet, which are parsed
+// as its elements after the paragraph on the bullet line, and ends at two
+// blank lines. Like in org, the first item decides the kind of a list; after
+// a blank line only items of the same kind continue it.
+func (p *parser) parseList(lines [][]byte, i int) (Node, int) {
+	list := new(List)
+	indent := lineIndentation(lines[i])
+	for i < len(lines) && isListItem(lines[i]) && lineIndentation(lines[i]) == indent {
+		start := i
+		item, kind, text := p.parseListItem(lines[i])
+		if len(list.Items) == 0 {
+			list.Kind = kind
+		}
+		list.Items = append(list.Items, item)
+
+		end := listItemEnd(lines, i, indent)
+		// the item's body is parsed from its own lines, counted from the
+		// bullet line
+		body := append([][]byte{lines[start]}, dedent(lines[start+1:end])...)
+		offset := p.lineOffset
+		p.lineOffset += start
+		para := [][]byte{text}
+		j := 1
+		for ; j < len(body) && !isEmpty(body[j]) && !startsElement(body[j]); j++ {
+			para = append(para, body[j])
+		}
+		item.Children = []Node{p.paragraph(para)}
+		headEnd := skipBlank(body, j, len(body))
+		children, _ := p.parseElements(body, headEnd, len(body), nil)
+		item.Children = append(item.Children, children...)
+		p.lineOffset = offset
+
+		next := skipBlank(lines, end, len(lines))
+		continues := next < len(lines) && isListItem(lines[next]) && lineIndentation(lines[next]) == indent
+		if continues && next > end {
+			_, nextKind, _ := p.parseListItem(lines[next])
+			continues = next == end+1 && nextKind == list.Kind
+		}
+		if continues {
+			end = next
+		}
+		p.record(item, start, end)
+		p.src[item].head = p.text(start, start+headEnd)
+		if i = end; !continues {
+			break
+		}
+	}
+	return list, i
+}
+
+// listItemEnd returns the index of the line after the last one of the list
+// item at lines[i], whose bullet is indented by indent.
+func listItemEnd(lines [][]byte, i, indent int) int {
+	end := i + 1
+	for j := i + 1; j < len(lines); j++ {
+		if isEmpty(lines[j]) {
+			if j+1 < len(lines) && isEmpty(lines[j+1]) {
+				break
+			}
+			continue
+		}
+		if lineIndentation(lines[j]) <= indent {
+			break
+		}
+		end = j + 1
+	}
+	return end
+}
+
+// dedent returns a copy of lines with the indentation they share removed, so
+// their elements can be parsed like unindented ones.
+func dedent(lines [][]byte) [][]byte {
+	indent := -1
+	for _, line := range lines {
+		if width := lineIndentation(line); !isEmpty(line) && (indent < 0 || width < indent) {
+			indent = width
+		}
+	}
+	body := make([][]byte, len(lines))
+	for j, line := range lines {
+		k, width := 0, 0
+		for ; k < len(line) && width < indent && (line[k] == ' ' || line[k] == '\t'); k++ {
+			if line[k] == '\t' {
+				width += 8 - width%8
+			} else {
+				width++
+			}
+		}
+		body[j] = line[k:]
+	}
+	return body
+}
+
+// lineIndentation returns the number of columns data is indented by.
+func lineIndentation(data []byte) int {
+	return displayWidth(string(data[:len(data)-len(bytes.TrimLeft(data, " \t"))]))
+}
+
+// parseListItem parses the bullet line of an item, returning the item, the
+// kind of list it belongs in and the text after its bullet.
+func (p *parser) parseListItem(data []byte) (*ListItem, ListKind, []byte) {
+	switch {
+	case isDefinitionList(data):
+		matches := reDefinitionList.FindSubmatch(data)
+		checkbox, term := parseCheckbox(matches[1])
+		return &ListItem{Bullet: "-", Checkbox: checkbox, Term: p.inline(term)}, DescriptiveList, matches[2]
+	case isUnorderedList(data):
+		matches := reUnorderedList.FindSubmatch(data)
+		checkbox, text := parseCheckbox(matches[2])
+		return &ListItem{Bullet: string(data[len(matches[1])]), Checkbox: checkbox}, UnorderedList, text
+	default:
+		matches := reOrderedList.FindSubmatch(data)
+		checkbox, text := parseCheckbox(matches[4])
+		return &ListItem{Bullet: string(matches[2]), Counter: string(matches[3]), Checkbox: checkbox}, OrderedList, text
+	}
+}
+
+// Elements
+func parseFixedWidth(lines [][]byte, i int) (Node, int) {
+	fixed := new(FixedWidth)
+	for ; i < len(lines) && isExampleLine(lines[i]); i++ {
+		matches := reExampleLine.FindSubmatch(lines[i])
+		fixed.Lines = append(fixed.Lines, string(matches[1]))
+	}
+	return fixed, i
+}
+
+func parseKeyword(data []byte) *Keyword {
+	data = data[2:]
+	if i := bytes.IndexByte(data, ':'); i >= 0 {
+		return &Keyword{Key: string(data[:i]), Value: string(bytes.TrimSpace(data[i+1:]))}
+	}
+	return &Keyword{Key: string(bytes.TrimSpace(data))}
+}
+
+func (p *parser) parseParagraph(lines [][]byte, i int) (Node, int) {
+	text := [][]byte{lines[i]}
+	for i++; i < len(lines) && !isEmpty(lines[i]) && !startsElement(lines[i]); i++ {
+		text = append(text, lines[i])
+	}
+	return p.paragraph(text), i
+}
+
+func (p *parser) paragraph(lines [][]byte) *Paragraph {
+	return &Paragraph{Children: p.inline(bytes.Trim(bytes.Join(lines, []byte("\n")), " "))}
+}
+
+// Objects
+func (p *parser) inline(data []byte) []Node {
+	var nodes []Node
+	var text bytes.Buffer
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, &Text{Value: text.String()})
+			text.Reset()
+		}
+	}
+
+	i, end := 0, 0
+	for i < len(data) {
+		for end < len(data) && p.inlineCallback[data[end]] == nil {
+			end++
+		}
+
+		text.Write(data[i:end])
+
+		if end >= len(data) {
+			break
+		}
+		i = end
+
+		handler := p.inlineCallback[data[i]]
+
+		if n, consumed := handler(p, data, i); consumed > 0 {
+			flush()
+			nodes = append(nodes, n)
+			i += consumed
+			end = i
+			continue
+		}
+
+		end = i + 1
+	}
+	flush()
+
+	return nodes
+}
+
+func markup(dataIn []byte, offset int, char byte) ([]byte, int) {
+	data := dataIn[offset:]
+	start := 1
+	i := start
+	if len(data) <= 1 {
+		return nil, 0
+	}
+
+	lastCharInside := findLastCharInInline(data, char)
+
+	// Org mode spec says a non-whitespace character must immediately follow.
+	// if the current char is the marker, then there's no text between, not a candidate
+	if isSpace(data[i]) || lastCharInside == i || !isAcceptablePreOpeningChar(dataIn, data, offset) {
+		return nil, 0
+	}
+
+	if lastCharInside > 0 {
+		return data[start:lastCharInside], lastCharInside + 1
+	}
+
+	return nil, 0
+}
+
+// ~~ Text Markup
+func parseVerbatim(p *parser, data []byte, offset int) (Node, int) {
+	text, consumed := markup(data, offset, '=')
+	if consumed == 0 {
+		return nil, 0
+	}
+	return &Code{Verbatim: true, Value: string(text)}, consumed
+}
+
+func parseCode(p *parser, data []byte, offset int) (Node, int) {
+	text, consumed := markup(data, offset, '~')
+	if consumed == 0 {
+		return nil, 0
+	}
+	return &Code{Value: string(text)}, consumed
+}
+
+func parseEmphasis(kind EmphasisKind) inlineParser {
+	return func(p *parser, data []byte, offset int) (Node, int) {
+		text, consumed := markup(data, offset, emphasisMarkers[kind])
+		if consumed == 0 {
+			return nil, 0
+		}
+		return &Emphasis{Kind: kind, Children: p.inline(text)}, consumed
+	}
+}
+
+// ~~ Timestamps
+func parseInlineTimestamp(p *parser, data []byte, offset int) (Node, int) {
+	if ts, consumed := parseTimestamp(data[offset:]); ts != nil {
+		return ts, consumed
+	}
+	return nil, 0
+}
+
+var emphasisMarkers = map[EmphasisKind]byte{
+	Bold:          '*',
+	Italic:        '/',
+	Underline:     '_',
+	StrikeThrough: '+',
+}
+
+// ~~ Images and Links (inc. Footnote), inactive timestamps and statistics cookies
+func parseLinkOrImg(p *parser, data []byte, offset int) (Node, int) {
+	if ts, consumed := parseTimestamp(data[offset:]); ts != nil {
+		return ts, consumed
+	}
+	if m := reStatisticsCookie.FindSubmatch(data[offset:]); m != nil {
+		return &StatisticsCookie{Value: string(m[1])}, len(m[0])
+	}
+
+	data = data[offset+1:]
+	start := 1
+	i := start
+	var hyperlink []byte
+	isImage := false
+	isFootnote := false
+	closedLink := false
+	hasContent := false
+
+	if bytes.HasPrefix(data, []byte("fn:")) {
+		isFootnote = true
+	} else if len(data) == 0 || data[0] != '[' {
+		return nil, 0
+	}
+
+	if bytes.HasPrefix(data[1:], []byte("file:")) {
+		isImage = true
+	}
+
+	for i < len(data) {
+		currChar := data[i]
+		switch {
+		case charMatches(currChar, ']') && closedLink == false:
+			if isFootnote {
+				refid := data[start+2 : i]
+				if bytes.Equal(refid, bytes.Trim(refid, " ")) {
+					return &FootnoteReference{Label: string(refid)}, i + 2
+				}
+				return nil, 0
+			}
+			hyperlink = data[start:i]
+			closedLink = true
+		case charMatches(currChar, '['):
+			start = i + 1
+			hasContent = true
+		case charMatches(currChar, ']') && closedLink == true && hasContent == true && isImage == true:
+			return &Link{URL: string(hyperlink), Description: []Node{&Text{Value: string(data[start:i])}}}, i + 3
+		case charMatches(currChar, ']') && closedLink == true && hasContent == true:
+			return &Link{URL: string(hyperlink), Description: p.inline(data[start:i])}, i + 3
+		case charMatches(currChar, ']') && closedLink == true:
+			return &Link{URL: string(hyperlink)}, i + 2
+		}
+		i++
+	}
+
+	return nil, 0
+}
+
+// orgString returns the org markup for a slice of inline nodes.
+func orgString(nodes []Node) string {
+	var out bytes.Buffer
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Text:
+			out.WriteString(n.Value)
+		case *Emphasis:
+			out.WriteByte(emphasisMarkers[n.Kind])
+			out.WriteString(orgString(n.Children))
+			out.WriteByte(emphasisMarkers[n.Kind])
+		case *Code:
+			marker := "~"
+			if n.Verbatim {
+				marker = "="
+			}
+			out.WriteString(marker + n.Value + marker)
+		case *Link:
+			out.WriteString("[[" + n.URL + "]")
+			if len(n.Description) > 0 {
+				out.WriteString("[" + orgString(n.Description) + "]")
+			}
+			out.WriteString("]")
+		case *FootnoteReference:
+			out.WriteString("[fn:" + n.Label + "]")
+		case *StatisticsCookie:
+			out.WriteString("[" + n.Value + "]")
+		case *Timestamp:
+			out.WriteString(timestampString(n))
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,421 @@
+package goorgeous
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	testCases := map[string]struct {
+		in       string
+		expected *Document
+	}{
+		"paragraph": {
+			"some *bold* text\nover two lines\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&Paragraph{Children: []Node{
+						&Text{Value: "some "},
+						&Emphasis{Kind: Bold, Children: []Node{&Text{Value: "bold"}}},
+						&Text{Value: " text\nover two lines"},
+					}},
+				}},
+			}},
+		},
+		"headlines": {
+			"intro\n* TODO [#A] first :a:b:\nbody\n** second\n* third\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{&Paragraph{Children: []Node{&Text{Value: "intro"}}}}},
+				&Headline{Level: 1, Keyword: "TODO", Priority: &Priority{Value: "A"}, Title: []Node{&Text{Value: "first"}}, Tags: []string{"a", "b"}, Children: []Node{
+					&Section{Children: []Node{&Paragraph{Children: []Node{&Text{Value: "body"}}}}},
+					&Headline{Level: 2, Title: []Node{&Text{Value: "second"}}},
+				}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "third"}}},
+			}},
+		},
+		"todo-keywords": {
+			"* TODOS not a keyword\n* DONE done\n* TODO\n",
+			&Document{Children: []Node{
+				&Headline{Level: 1, Title: []Node{&Text{Value: "TODOS not a keyword"}}},
+				&Headline{Level: 1, Keyword: "DONE", Done: true, Title: []Node{&Text{Value: "done"}}},
+				&Headline{Level: 1, Keyword: "TODO"},
+			}},
+		},
+		"custom-todo-keywords": {
+			"#+TODO: NEXT WAIT(w@/!) | CANCELLED(c)\n#+typ_todo: Fred Sara\n* WAIT a\n* CANCELLED b\n* TODO c\n* Sara d\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&Keyword{Key: "TODO", Value: "NEXT WAIT(w@/!) | CANCELLED(c)"},
+					&Keyword{Key: "typ_todo", Value: "Fred Sara"},
+				}},
+				&Headline{Level: 1, Keyword: "WAIT", Title: []Node{&Text{Value: "a"}}},
+				&Headline{Level: 1, Keyword: "CANCELLED", Done: true, Title: []Node{&Text{Value: "b"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "TODO c"}}},
+				&Headline{Level: 1, Keyword: "Sara", Done: true, Title: []Node{&Text{Value: "d"}}},
+			}},
+		},
+		"planning": {
+			"* TODO a\n  DEADLINE: <2024-01-05 Fri> SCHEDULED: <2024-01-02 Tue 9:30>\n* DONE b\nCLOSED: [2024-01-03 Wed 17:00]\n* c\n\nSCHEDULED: <2024-01-02 Tue>\n* d\nSCHEDULED: tomorrow\n",
+			&Document{Children: []Node{
+				&Headline{Level: 1, Keyword: "TODO", Title: []Node{&Text{Value: "a"}}, Planning: &Planning{
+					Scheduled: &Timestamp{Active: true, Time: time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC), HasTime: true},
+					Deadline:  &Timestamp{Active: true, Time: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+				}},
+				&Headline{Level: 1, Keyword: "DONE", Done: true, Title: []Node{&Text{Value: "b"}}, Planning: &Planning{
+					Closed: &Timestamp{Time: time.Date(2024, 1, 3, 17, 0, 0, 0, time.UTC), HasTime: true},
+				}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "c"}}, Children: []Node{
+					&Section{Children: []Node{&Paragraph{Children: []Node{
+						&Text{Value: "SCHEDULED: "},
+						&Timestamp{Active: true, Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+					}}}},
+				}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "d"}}, Children: []Node{
+					&Section{Children: []Node{&Paragraph{Children: []Node{&Text{Value: "SCHEDULED: tomorrow"}}}}},
+				}},
+			}},
+		},
+		"properties": {
+			"* a\nSCHEDULED: <2024-01-02 Tue>\n  :properties:\n  :Effort: 1:00\n  :VAR: x=1\n  :var+: y=2\n  :TAGS+: z\n  not a property\n  :EMPTY:\n  :end:\ntext\n* b\n\n:PROPERTIES:\n:ID: 1\n:END:\n",
+			&Document{Children: []Node{
+				&Headline{Level: 1, Title: []Node{&Text{Value: "a"}},
+					Planning:   &Planning{Scheduled: &Timestamp{Active: true, Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+					Properties: map[string]string{"Effort": "1:00", "VAR": "x=1 y=2", "TAGS+": "z", "EMPTY": ""},
+					Children:   []Node{&Section{Children: []Node{&Paragraph{Children: []Node{&Text{Value: "text"}}}}}},
+				},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "b"}}, Children: []Node{
+					&Section{Children: []Node{&Drawer{Name: "PROPERTIES", Lines: []string{":ID: 1"}}}},
+				}},
+			}},
+		},
+		"drawers": {
+			"* a\n:LOGBOOK:\nCLOCK: [2024-01-02 Tue 10:00]--[2024-01-02 Tue 11:30] =>  1:30\n  CLOCK: [2024-01-03 Wed 9:00]\n- note\n:END:\n:notes:\ntext\n:end:\n:UNCLOSED:\n* b\n",
+			&Document{Children: []Node{
+				&Headline{Level: 1, Title: []Node{&Text{Value: "a"}}, Children: []Node{
+					&Section{Children: []Node{
+						&Drawer{Name: "LOGBOOK", Children: []Node{
+							&Clock{Timestamp: &Timestamp{Time: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), HasTime: true, End: time.Date(2024, 1, 2, 11, 30, 0, 0, time.UTC)}, Duration: 90 * time.Minute},
+							&Clock{Timestamp: &Timestamp{Time: time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), HasTime: true}},
+							&List{Items: []*ListItem{{Bullet: "-", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "note"}}}}}}},
+						}},
+						&Drawer{Name: "notes", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "text"}}}}},
+						&Paragraph{Children: []Node{&Text{Value: ":UNCLOSED:"}}},
+					}},
+				}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "b"}}},
+			}},
+		},
+		"timestamps": {
+			"due <2024-01-02 Tue> at [2024-01-02 Tue 9:30], <2024-01-02 Tue 10:00-12:00>\n<2024-01-05 Fri>--<2024-01-07 Sun> <2024-01-01 Mon +1w -2d> [2024-01-31 .+1m --1d]\n<2024-13-01> <not a date> [[link]]\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{&Paragraph{Children: []Node{
+					&Text{Value: "due "},
+					&Timestamp{Active: true, Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+					&Text{Value: " at "},
+					&Timestamp{Time: time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC), HasTime: true},
+					&Text{Value: ", "},
+					&Timestamp{Active: true, Time: time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC), HasTime: true, End: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)},
+					&Text{Value: "\n"},
+					&Timestamp{Active: true, Time: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+					&Text{Value: " "},
+					&Timestamp{Active: true, Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Repeater: &Repeater{Mark: "+", Value: 1, Unit: "w"}, Delay: &Delay{Value: 2, Unit: "d"}},
+					&Text{Value: " "},
+					&Timestamp{Time: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Repeater: &Repeater{Mark: ".+", Value: 1, Unit: "m"}, Delay: &Delay{FirstOnly: true, Value: 1, Unit: "d"}},
+					&Text{Value: "\n<2024-13-01> <not a date> "},
+					&Link{URL: "link"},
+				}}}},
+			}},
+		},
+		"priorities": {
+			"* [#B] b\n* TODO [#C]c\n* [#D] out of range\n* [A] no hash\n",
+			&Document{Children: []Node{
+				&Headline{Level: 1, Priority: &Priority{Value: "B", Rank: 1}, Title: []Node{&Text{Value: "b"}}},
+				&Headline{Level: 1, Keyword: "TODO", Priority: &Priority{Value: "C", Rank: 2}, Title: []Node{&Text{Value: "c"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "[#D] out of range"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "[A] no hash"}}},
+			}},
+		},
+		"custom-priorities": {
+			"#+PRIORITIES: A E C\n* [#D] d\n* [#F] f\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{&Keyword{Key: "PRIORITIES", Value: "A E C"}}},
+				&Headline{Level: 1, Priority: &Priority{Value: "D", Rank: 3}, Title: []Node{&Text{Value: "d"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "[#F] f"}}},
+			}},
+		},
+		"numeric-priorities": {
+			"#+priorities: 1 10 5\n* [#1] one\n* [#10] ten\n* [#11] eleven\n* [#A] a\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{&Keyword{Key: "priorities", Value: "1 10 5"}}},
+				&Headline{Level: 1, Priority: &Priority{Value: "1", Rank: 0}, Title: []Node{&Text{Value: "one"}}},
+				&Headline{Level: 1, Priority: &Priority{Value: "10", Rank: 9}, Title: []Node{&Text{Value: "ten"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "[#11] eleven"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "[#A] a"}}},
+			}},
+		},
+		"deep-headlines": {
+			"******* seven\n******** eight\n*******not a headline\n",
+			&Document{Children: []Node{
+				&Headline{Level: 7, Title: []Node{&Text{Value: "seven"}}, Children: []Node{
+					&Headline{Level: 8, Title: []Node{&Text{Value: "eight"}}, Children: []Node{
+						&Section{Children: []Node{&Paragraph{Children: []Node{&Text{Value: "*******not a headline"}}}}},
+					}},
+				}},
+			}},
+		},
+		"lists": {
+			"- a\n- b\n\n1. one\n2. [@5] five\n\n- term :: def\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&List{Kind: UnorderedList, Items: []*ListItem{
+						{Bullet: "-", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "a"}}}}},
+						{Bullet: "-", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "b"}}}}},
+					}},
+					&List{Kind: OrderedList, Items: []*ListItem{
+						{Bullet: "1.", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "one"}}}}},
+						{Bullet: "2.", Counter: "5", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "five"}}}}},
+					}},
+					&List{Kind: DescriptiveList, Items: []*ListItem{
+						{Bullet: "-", Term: []Node{&Text{Value: "term"}}, Children: []Node{&Paragraph{Children: []Node{&Text{Value: "def"}}}}},
+					}},
+				}},
+			}},
+		},
+		"nested-lists": {
+			"- a\n  1. one\n     - term :: def\n  2. two\n- b\n    - deep\n  - shallow\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&List{Kind: UnorderedList, Items: []*ListItem{
+						{Bullet: "-", Children: []Node{
+							&Paragraph{Children: []Node{&Text{Value: "a"}}},
+							&List{Kind: OrderedList, Items: []*ListItem{
+								{Bullet: "1.", Children: []Node{
+									&Paragraph{Children: []Node{&Text{Value: "one"}}},
+									&List{Kind: DescriptiveList, Items: []*ListItem{
+										{Bullet: "-", Term: []Node{&Text{Value: "term"}}, Children: []Node{&Paragraph{Children: []Node{&Text{Value: "def"}}}}},
+									}},
+								}},
+								{Bullet: "2.", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "two"}}}}},
+							}},
+						}},
+						{Bullet: "-", Children: []Node{
+							&Paragraph{Children: []Node{&Text{Value: "b"}}},
+							&List{Kind: UnorderedList, Items: []*ListItem{
+								{Bullet: "-", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "deep"}}}}},
+							}},
+							&List{Kind: UnorderedList, Items: []*ListItem{
+								{Bullet: "-", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "shallow"}}}}},
+							}},
+						}},
+					}},
+				}},
+			}},
+		},
+		"multi-line-items": {
+			"- a\n  wrapped\n\n  second\n  | 1 |\n\n- b\n\n\n- c\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&List{Kind: UnorderedList, Items: []*ListItem{
+						{Bullet: "-", Children: []Node{
+							&Paragraph{Children: []Node{&Text{Value: "a\nwrapped"}}},
+							&Paragraph{Children: []Node{&Text{Value: "second"}}},
+							&Table{Rows: []*TableRow{{Cells: []*TableCell{{Children: []Node{&Text{Value: "1"}}}}}}},
+						}},
+						{Bullet: "-", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "b"}}}}},
+					}},
+					&List{Kind: UnorderedList, Items: []*ListItem{
+						{Bullet: "-", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "c"}}}}},
+					}},
+				}},
+			}},
+		},
+		"checkboxes": {
+			"- [X] done [1/2]\n- [ ]\n- [-] [50%]\n- [x] not a checkbox\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&List{Kind: UnorderedList, Items: []*ListItem{
+						{Bullet: "-", Checkbox: "X", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "done "}, &StatisticsCookie{Value: "1/2"}}}}},
+						{Bullet: "-", Checkbox: " ", Children: []Node{&Paragraph{}}},
+						{Bullet: "-", Checkbox: "-", Children: []Node{&Paragraph{Children: []Node{&StatisticsCookie{Value: "50%"}}}}},
+						{Bullet: "-", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "[x] not a checkbox"}}}}},
+					}},
+				}},
+			}},
+		},
+		"table": {
+			"| a | b |\n|---+---|\n| =1= | 2 |\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&Table{Rows: []*TableRow{
+						{Cells: []*TableCell{{Children: []Node{&Text{Value: "a"}}}, {Children: []Node{&Text{Value: "b"}}}}},
+						{Rule: true},
+						{Cells: []*TableCell{{Children: []Node{&Code{Verbatim: true, Value: "1"}}}, {Children: []Node{&Text{Value: "2"}}}}},
+					}},
+				}},
+			}},
+		},
+		"table-formulas": {
+			"| 1 | 2 |\n#+TBLFM: $2=$1*2\n#+tblfm: @1$1=0\n#+TITLE: t\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&Table{
+						Rows:     []*TableRow{{Cells: []*TableCell{{Children: []Node{&Text{Value: "1"}}}, {Children: []Node{&Text{Value: "2"}}}}}},
+						Formulas: []string{"$2=$1*2", "@1$1=0"},
+					},
+					&Keyword{Key: "TITLE", Value: "t"},
+				}},
+			}},
+		},
+		"affiliated-keywords": {
+			"#+NAME: t\n#+CAPTION: a\n#+CAPTION: /b/\n#+ATTR_HTML: :class x\n#+attr_html: :border 1\n| 1 |\n#+CAPTION: unused\n\ntext\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&Table{
+						Affiliated: &Affiliated{
+							Name:       "t",
+							Caption:    []Node{&Text{Value: "a "}, &Emphasis{Kind: Italic, Children: []Node{&Text{Value: "b"}}}},
+							Attributes: map[string]string{"html": ":class x :border 1"},
+						},
+						Rows: []*TableRow{{Cells: []*TableCell{{Children: []Node{&Text{Value: "1"}}}}}},
+					},
+					&Keyword{Key: "CAPTION", Value: "unused"},
+					&Paragraph{Children: []Node{&Text{Value: "text"}}},
+				}},
+			}},
+		},
+		"blocks-and-drawers": {
+			"* h\n:PROPERTIES:\n:ID: 1\n:END:\n#+BEGIN_SRC sh -n\necho\n#+END_SRC\n#+BEGIN_QUOTE\nquoted\n#+END_QUOTE\n",
+			&Document{Children: []Node{
+				&Headline{Level: 1, Title: []Node{&Text{Value: "h"}}, Properties: map[string]string{"ID": "1"}, Children: []Node{
+					&Section{Children: []Node{
+						&Block{Name: "SRC", Parameters: []string{"sh", "-n"}, Lines: []string{"echo"}},
+						&Block{Name: "QUOTE", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "quoted"}}}}},
+					}},
+				}},
+			}},
+		},
+		"elements": {
+			"#+TITLE: a title\n# a comment\n-----\n: fixed\n: width\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&Keyword{Key: "TITLE", Value: "a title"},
+					&Comment{Value: "a comment"},
+					&HorizontalRule{},
+					&FixedWidth{Lines: []string{"fixed", "width"}},
+				}},
+			}},
+		},
+		"links-and-footnotes": {
+			"see [[https://example.com][/the/ site]][fn:1]\n\n[fn:1] a note\n",
+			&Document{Children: []Node{
+				&Section{Children: []Node{
+					&Paragraph{Children: []Node{
+						&Text{Value: "see "},
+						&Link{URL: "https://example.com", Description: []Node{
+							&Emphasis{Kind: Italic, Children: []Node{&Text{Value: "the"}}},
+							&Text{Value: " site"},
+						}},
+						&FootnoteReference{Label: "1"},
+					}},
+					&FootnoteDefinition{Label: "1", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "a note"}}}}},
+				}},
+			}},
+		},
+	}
+
+	for caseName, tc := range testCases {
+		doc, err := Parse([]byte(tc.in))
+		if err != nil {
+			t.Fatalf("case %s: Parse(%q) returned error: %s", caseName, tc.in, err)
+		}
+		if !reflect.DeepEqual(doc.Children, tc.expected.Children) {
+			got, _ := json.Marshal(doc.Children)
+			wants, _ := json.Marshal(tc.expected.Children)
+			t.Errorf("case %s: Parse(%q) = %s\nwants: %s", caseName, tc.in, got, wants)
+		}
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	opts := ParseOptions{TodoKeywords: []string{"NEXT | DONE", "BUG FIXED"}, Priorities: "1 64 32"}
+
+	testCases := map[string]struct {
+		in       string
+		expected []Node
+	}{
+		"defaults": {
+			"* NEXT a\n* FIXED b\n* TODO c\n",
+			[]Node{
+				&Headline{Level: 1, Keyword: "NEXT", Title: []Node{&Text{Value: "a"}}},
+				&Headline{Level: 1, Keyword: "FIXED", Done: true, Title: []Node{&Text{Value: "b"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "TODO c"}}},
+			},
+		},
+		"document-keywords-win": {
+			"#+SEQ_TODO: TODO | DONE\n* NEXT a\n* TODO b\n",
+			[]Node{
+				&Section{Children: []Node{&Keyword{Key: "SEQ_TODO", Value: "TODO | DONE"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "NEXT a"}}},
+				&Headline{Level: 1, Keyword: "TODO", Title: []Node{&Text{Value: "b"}}},
+			},
+		},
+		"default-priorities": {
+			"* [#64] a\n* [#A] b\n",
+			[]Node{
+				&Headline{Level: 1, Priority: &Priority{Value: "64", Rank: 63}, Title: []Node{&Text{Value: "a"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "[#A] b"}}},
+			},
+		},
+		"document-priorities-win": {
+			"#+PRIORITIES: A B B\n* [#64] a\n* [#A] b\n",
+			[]Node{
+				&Section{Children: []Node{&Keyword{Key: "PRIORITIES", Value: "A B B"}}},
+				&Headline{Level: 1, Title: []Node{&Text{Value: "[#64] a"}}},
+				&Headline{Level: 1, Priority: &Priority{Value: "A"}, Title: []Node{&Text{Value: "b"}}},
+			},
+		},
+	}
+
+	for caseName, tc := range testCases {
+		doc, _ := ParseWithOptions([]byte(tc.in), opts)
+		if !reflect.DeepEqual(doc.Children, tc.expected) {
+			got, _ := json.Marshal(doc)
+			t.Errorf("case %s: ParseWithOptions(%q) = %s", caseName, tc.in, got)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	doc, err := Parse([]byte("* one [[https://example.com][link]]\n- *item*\n"))
+	if err != nil {
+		t.Fatalf("Parse returned error: %s", err)
+	}
+
+	var links, emphasis, headlines int
+	Walk(doc, func(n Node) bool {
+		switch n.(type) {
+		case *Link:
+			links++
+		case *Emphasis:
+			emphasis++
+		case *Headline:
+			headlines++
+		}
+		return true
+	})
+	if links != 1 || emphasis != 1 || headlines != 1 {
+		t.Errorf("Walk() visited %d links, %d emphasis and %d headlines\nwants: 1, 1 and 1", links, emphasis, headlines)
+	}
+
+	visited := 0
+	Walk(doc, func(n Node) bool {
+		visited++
+		_, isDocument := n.(*Document)
+		return isDocument
+	})
+	if visited != 2 {
+		t.Errorf("Walk() visited %d nodes when skipping children\nwants: 2", visited)
+	}
+}
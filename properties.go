@@ -0,0 +1,117 @@
+package goorgeous
+
+import (
+	"sort"
+	"strings"
+)
+
+// Property returns the value of the property key in the property drawer of
+// h. Keys are matched ignoring case, as in org.
+func (h *Headline) Property(key string) (string, bool) {
+	name, ok := propertyKey(h.Properties, key)
+	return h.Properties[name], ok
+}
+
+// InheritedProperty returns the value of the property key of h with org's
+// property inheritance: the value set by the closest of h and its ancestors,
+// or else by a #+PROPERTY: KEY value line. KEY+ properties and #+PROPERTY
+// lines append their value to the one inherited so far. It reports false
+// when no value is set or h isn't part of d.
+func (d *Document) InheritedProperty(h *Headline, key string) (string, bool) {
+	path := headlinePath(d.Children, h)
+	if path == nil {
+		return "", false
+	}
+
+	var value string
+	var found bool
+	set := func(v string, ok, extend bool) {
+		switch {
+		case !ok:
+		case extend && found:
+			value = joinPropertyValues(value, v)
+		default:
+			value, found = v, true
+		}
+	}
+
+	Walk(d, func(n Node) bool {
+		if k, ok := n.(*Keyword); ok && strings.EqualFold(k.Key, "PROPERTY") {
+			name := strings.TrimSpace(k.Value)
+			v := ""
+			if i := strings.IndexAny(name, " \t"); i >= 0 {
+				name, v = name[:i], strings.TrimSpace(name[i:])
+			}
+			set(v, strings.EqualFold(name, key), false)
+			set(v, strings.EqualFold(name, key+"+"), true)
+		}
+		return true
+	})
+	for _, ancestor := range path {
+		v, ok := ancestor.Property(key)
+		set(v, ok, false)
+		v, ok = ancestor.Property(key + "+")
+		set(v, ok, true)
+	}
+	return value, found
+}
+
+// headlinePath returns the headlines from the top level down to h, or nil if
+// nodes don't hold h.
+func headlinePath(nodes []Node, h *Headline) []*Headline {
+	for _, n := range nodes {
+		if headline, ok := n.(*Headline); ok {
+			if headline == h {
+				return []*Headline{h}
+			}
+			if path := headlinePath(headline.Children, h); path != nil {
+				return append([]*Headline{headline}, path...)
+			}
+		}
+	}
+	return nil
+}
+
+// propertyKey returns the key of properties that matches key ignoring case.
+func propertyKey(properties map[string]string, key string) (string, bool) {
+	if _, ok := properties[key]; ok {
+		return key, true
+	}
+	for name := range properties {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func joinPropertyValues(value, extra string) string {
+	if value == "" {
+		return extra
+	}
+	return value + " " + extra
+}
+
+// propertyNames returns the keys of properties in sorted order.
+func propertyNames(properties map[string]string) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// propertyDrawerString returns the org form of a property drawer.
+func propertyDrawerString(properties map[string]string) string {
+	text := ":PROPERTIES:\n"
+	for _, name := range propertyNames(properties) {
+		text += propertyLine(name, properties[name])
+	}
+	return text + ":END:\n"
+}
+
+// propertyLine returns the org form of a property drawer line.
+func propertyLine(name, value string) string {
+	return strings.TrimRight(":"+name+": "+value, " ") + "\n"
+}
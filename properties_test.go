@@ -0,0 +1,44 @@
+package goorgeous
+
+import (
+	"testing"
+)
+
+func TestInheritedProperty(t *testing.T) {
+	in := "#+PROPERTY: header-args :results silent\n#+property: VAR x=1\n" +
+		"* top\n:PROPERTIES:\n:OWNER: ann\n:VAR+: y=2\n:END:\n" +
+		"** middle\n:PROPERTIES:\n:owner: bob\n:END:\n" +
+		"*** bottom\n:PROPERTIES:\n:Var+: z=3\n:END:\n" +
+		"* other\n"
+	doc, _ := Parse([]byte(in))
+	top := doc.Children[1].(*Headline)
+	middle := top.Children[0].(*Headline)
+	bottom := middle.Children[0].(*Headline)
+	other := doc.Children[2].(*Headline)
+
+	testCases := map[string]struct {
+		h        *Headline
+		key      string
+		expected string
+		ok       bool
+	}{
+		"own":              {top, "OWNER", "ann", true},
+		"closest-ancestor": {bottom, "Owner", "bob", true},
+		"not-set":          {other, "OWNER", "", false},
+		"file-level":       {other, "HEADER-ARGS", ":results silent", true},
+		"accumulated":      {bottom, "VAR", "x=1 y=2 z=3", true},
+		"not-accumulated":  {other, "VAR", "x=1", true},
+		"other-document":   {&Headline{}, "VAR", "", false},
+	}
+
+	for caseName, tc := range testCases {
+		value, ok := doc.InheritedProperty(tc.h, tc.key)
+		if value != tc.expected || ok != tc.ok {
+			t.Errorf("case %s: InheritedProperty(%q) = %q, %t\nwants: %q, %t", caseName, tc.key, value, ok, tc.expected, tc.ok)
+		}
+	}
+
+	if value, ok := middle.Property("OWNER"); value != "bob" || !ok {
+		t.Errorf("Property(%q) = %q, %t\nwants: %q, %t", "OWNER", value, ok, "bob", true)
+	}
+}
@@ -0,0 +1,171 @@
+package goorgeous
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var reCheckbox = regexp.MustCompile(`^\[([ X-])\](?:[ \t]+|$)`)
+
+// parseCheckbox splits the checkbox off the text after a list item's bullet.
+func parseCheckbox(text []byte) (string, []byte) {
+	if m := reCheckbox.FindSubmatch(text); m != nil {
+		return string(m[1]), text[len(m[0]):]
+	}
+	return "", text
+}
+
+var reStatisticsCookie = regexp.MustCompile(`^\[(\d*%|\d*/\d*)\]`)
+
+// UpdateStatistics recomputes the statistics cookies of d, and the
+// checkboxes of list items whose sub items have checkboxes, the way org
+// does when a checkbox or TODO keyword changes.
+//
+// A cookie in a list item counts the checkboxes of its direct sub items. A
+// cookie in a headline counts its child headlines with a TODO keyword, or
+// the checkboxes of the top level items in its section when it has none. A
+// COOKIE_DATA property of "todo" or "checkbox" picks either, and adding
+// "recursive" counts all sub headlines or items instead of direct ones.
+//
+// A checkbox is checked when all of its sub items are, partially checked
+// ([-]) when some are, and unchecked otherwise.
+func (d *Document) UpdateStatistics() {
+	Walk(d, func(n Node) bool {
+		if item, ok := n.(*ListItem); ok {
+			updateItem(item)
+			return false
+		}
+		return true
+	})
+
+	Walk(d, func(n Node) bool {
+		if h, ok := n.(*Headline); ok {
+			updateHeadline(h)
+		}
+		return true
+	})
+}
+
+// updateItem updates item from its sub items, after updating those.
+func updateItem(item *ListItem) {
+	first, rest := listItemBody(item)
+	for _, n := range rest {
+		Walk(n, func(n Node) bool {
+			if sub, ok := n.(*ListItem); ok {
+				updateItem(sub)
+				return false
+			}
+			return true
+		})
+	}
+
+	c := countCheckboxes(rest, false)
+	if item.Checkbox != "" && c.total > 0 {
+		switch {
+		case c.done == c.total:
+			item.Checkbox = "X"
+		case c.done > 0 || c.partial > 0:
+			item.Checkbox = "-"
+		default:
+			item.Checkbox = " "
+		}
+	}
+	setCookies(append(append([]Node{}, item.Term...), first...), c.done, c.total)
+}
+
+func updateHeadline(h *Headline) {
+	data, _ := h.Property("COOKIE_DATA")
+	recursive := strings.Contains(data, "recursive")
+	todo := strings.Contains(data, "todo")
+	if !todo && !strings.Contains(data, "checkbox") {
+		todo = countTodos(h.Children, true).total > 0
+	}
+
+	var c checkboxCount
+	switch {
+	case todo:
+		c = countTodos(h.Children, !recursive)
+	case len(h.Children) > 0:
+		if section, ok := h.Children[0].(*Section); ok {
+			c = countCheckboxes(section.Children, recursive)
+		}
+	}
+	setCookies(h.Title, c.done, c.total)
+}
+
+// checkboxCount counts checkboxes or TODO keywords, and how many of them
+// are checked or done.
+type checkboxCount struct {
+	done, partial, total int
+}
+
+// countCheckboxes counts the checkboxes of the list items in nodes, leaving
+// out those in drawers and, unless recursive is set, sub items.
+func countCheckboxes(nodes []Node, recursive bool) checkboxCount {
+	var c checkboxCount
+	for _, n := range nodes {
+		Walk(n, func(n Node) bool {
+			switch n := n.(type) {
+			case *Drawer:
+				return false
+			case *ListItem:
+				if n.Checkbox != "" {
+					c.total++
+				}
+				switch n.Checkbox {
+				case "X":
+					c.done++
+				case "-":
+					c.partial++
+				}
+				return recursive
+			}
+			return true
+		})
+	}
+	return c
+}
+
+// countTodos counts the headlines in nodes with a TODO keyword, only the
+// top level ones if direct is set.
+func countTodos(nodes []Node, direct bool) checkboxCount {
+	var c checkboxCount
+	for _, n := range nodes {
+		if h, ok := n.(*Headline); ok {
+			if h.Keyword != "" {
+				c.total++
+			}
+			if h.Done {
+				c.done++
+			}
+			if !direct {
+				sub := countTodos(h.Children, false)
+				c.done, c.total = c.done+sub.done, c.total+sub.total
+			}
+		}
+	}
+	return c
+}
+
+// setCookies sets the statistics cookies in nodes to done out of total.
+func setCookies(nodes []Node, done, total int) {
+	for _, n := range nodes {
+		Walk(n, func(n Node) bool {
+			cookie, ok := n.(*StatisticsCookie)
+			if !ok {
+				return true
+			}
+			if strings.HasSuffix(cookie.Value, "%") {
+				percent := 0
+				if total > 0 {
+					percent = 100 * done / total
+				}
+				cookie.Value = strconv.Itoa(percent) + "%"
+			} else {
+				cookie.Value = strconv.Itoa(done) + "/" + strconv.Itoa(total)
+			}
+			return false
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package goorgeous
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUpdateStatistics(t *testing.T) {
+	testCases := map[string]testCase{
+		"checkboxes": {
+			"- [ ] list [/]\n  - [X] a\n  - [ ] b [%]\n    - [X] c\n    - [-] d\n- [ ] all [%]\n  - [X] e\n",
+			"- [-] list [1/2]\n  - [X] a\n  - [-] b [50%]\n    - [X] c\n    - [-] d\n- [X] all [100%]\n  - [X] e\n",
+		},
+		"todo-children": {
+			"* project [%]\n- [X] not counted\n** DONE a\n** TODO b [0/0]\n*** DONE c\n** notes\n",
+			"* project [50%]\n- [X] not counted\n** DONE a\n** TODO b [1/1]\n*** DONE c\n** notes\n",
+		},
+		"section-checkboxes": {
+			"* shopping [/]\n- [X] milk\n- [ ] eggs\n  - [X] brown\n** sub\n- [X] not counted\n",
+			"* shopping [2/2]\n- [X] milk\n- [X] eggs\n  - [X] brown\n** sub\n- [X] not counted\n",
+		},
+		"cookie-data": {
+			"* a [/]\n:PROPERTIES:\n:COOKIE_DATA: todo recursive\n:END:\n** TODO b\n*** DONE c\n* d [/]\n:PROPERTIES:\n:COOKIE_DATA: checkbox recursive\n:END:\n- [ ] e\n  - [X] f\n- [ ] h\n** TODO g\n",
+			"* a [1/2]\n:PROPERTIES:\n:COOKIE_DATA: todo recursive\n:END:\n** TODO b\n*** DONE c\n* d [2/3]\n:PROPERTIES:\n:COOKIE_DATA: checkbox recursive\n:END:\n- [X] e\n  - [X] f\n- [ ] h\n** TODO g\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		doc, _ := Parse([]byte(tc.in))
+		doc.UpdateStatistics()
+		var out bytes.Buffer
+		Write(&out, doc)
+		if out.String() != tc.expected {
+			t.Errorf("case %s: UpdateStatistics() on %q = %q\nwants: %q", caseName, tc.in, out.String(), tc.expected)
+		}
+	}
+}
+
+func TestParseUpdateStatistics(t *testing.T) {
+	in := "* shopping [/]\n- [X] milk [%]\n- [ ] eggs\n"
+
+	doc, _ := ParseWithOptions([]byte(in), ParseOptions{UpdateStatistics: true})
+	var out bytes.Buffer
+	NewHTMLRenderer(HTMLOptions{}).Render(&out, doc)
+	expected := "<h1 id=\"shopping-1-2\">shopping <code>[1/2]</code></h1>\n<ul>\n<li class=\"on\"><input type=\"checkbox\" checked disabled> milk <code>[0%]</code></li>\n<li class=\"off\"><input type=\"checkbox\" disabled> eggs</li>\n</ul>\n"
+	if out.String() != expected {
+		t.Errorf("Render() = %q\nwants: %q", out.String(), expected)
+	}
+
+	out.Reset()
+	Write(&out, doc)
+	expected = "* shopping [1/2]\n- [X] milk [0%]\n- [ ] eggs\n"
+	if out.String() != expected {
+		t.Errorf("Write() = %q\nwants: %q", out.String(), expected)
+	}
+}
@@ -0,0 +1,178 @@
+package goorgeous
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ColumnAlignment is the alignment of a table column.
+type ColumnAlignment int
+
+// The alignments of table columns.
+const (
+	AlignLeft ColumnAlignment = iota
+	AlignRight
+	AlignCenter
+)
+
+// TableColumn describes a column of a Table, as set by the cookies in its
+// special rows.
+type TableColumn struct {
+	// Align is the alignment set by a <l>, <c> or <r> cookie. Like in org,
+	// columns without one are right aligned when at least half of their
+	// non-empty cells hold numbers, and left aligned otherwise.
+	Align ColumnAlignment
+
+	// Width is the width set by a cookie like <10> or <l10>, or zero.
+	Width int
+
+	// GroupStart is set for the first column of every column group, when the
+	// table has a row starting with / that groups its columns: < starts a
+	// group, > ends one and <> makes a column a group of its own.
+	GroupStart bool
+}
+
+var reTableCookie = regexp.MustCompile(`^<([lrc])?([0-9]*)>$`)
+
+// reTableNumber matches the cells org counts as numbers when aligning
+// columns.
+var reTableNumber = regexp.MustCompile(`^[<>]?[.^+\-0-9]*[0-9][:%)(xDdEe.^+\-0-9]*$|^[<>]?[-+]?0[xX][0-9a-fA-F.]+$|^[-+u]?inf$|^nan$`)
+
+// Columns returns the columns of t, as many as its widest row has cells.
+func (t *Table) Columns() []TableColumn {
+	columns := make([]TableColumn, tableColumns(t))
+	aligned := make([]bool, len(columns))
+	numbers, filled := make([]int, len(columns)), make([]int, len(columns))
+	grouped := false
+
+	for _, row := range t.Rows {
+		switch {
+		case isColumnGroupRow(row):
+			grouped = true
+			for i, cell := range row.Cells[1:] {
+				switch cellText(cell) {
+				case "<":
+					columns[i+1].GroupStart = true
+				case ">":
+					if i+2 < len(columns) {
+						columns[i+2].GroupStart = true
+					}
+				case "<>":
+					columns[i+1].GroupStart = true
+					if i+2 < len(columns) {
+						columns[i+2].GroupStart = true
+					}
+				}
+			}
+		case isCookieRow(row):
+			for i, cell := range row.Cells {
+				m := reTableCookie.FindStringSubmatch(cellText(cell))
+				if m == nil {
+					continue
+				}
+				if m[1] != "" {
+					columns[i].Align = map[string]ColumnAlignment{"l": AlignLeft, "r": AlignRight, "c": AlignCenter}[m[1]]
+					aligned[i] = true
+				}
+				columns[i].Width, _ = strconv.Atoi(m[2])
+			}
+		case !row.Rule:
+			for i, cell := range row.Cells {
+				if text := cellText(cell); text != "" {
+					filled[i]++
+					if reTableNumber.MatchString(text) {
+						numbers[i]++
+					}
+				}
+			}
+		}
+	}
+
+	for i := range columns {
+		if !aligned[i] && numbers[i] > 0 && 2*numbers[i] >= filled[i] {
+			columns[i].Align = AlignRight
+		}
+	}
+	if grouped && len(columns) > 0 {
+		columns[0].GroupStart = true
+	}
+	return columns
+}
+
+// isSpecialRow reports whether row sets up the columns of its table instead
+// of holding data, so it isn't exported.
+func isSpecialRow(row *TableRow) bool {
+	return isColumnGroupRow(row) || isCookieRow(row)
+}
+
+// isColumnGroupRow reports whether row is a | / | < | > | row grouping the
+// columns of its table.
+func isColumnGroupRow(row *TableRow) bool {
+	return len(row.Cells) > 0 && cellText(row.Cells[0]) == "/"
+}
+
+// isCookieRow reports whether row holds alignment or width cookies and
+// nothing else.
+func isCookieRow(row *TableRow) bool {
+	cookies := false
+	for _, cell := range row.Cells {
+		switch text := cellText(cell); {
+		case text == "<>":
+			return false
+		case reTableCookie.MatchString(text):
+			cookies = true
+		case text != "":
+			return false
+		}
+	}
+	return cookies
+}
+
+// Header returns the header rows of t: the rows above its first rule, unless
+// the table starts with a rule or has no other rule.
+func (t *Table) Header() []*TableRow {
+	rows := dataRows(t)
+	for i, row := range rows {
+		if row.Rule {
+			return rows[:i]
+		}
+	}
+	return nil
+}
+
+// Bodies returns the rows of t below its header, in the groups its rules
+// separate them into. Rules themselves are left out.
+func (t *Table) Bodies() [][]*TableRow {
+	var bodies [][]*TableRow
+	var body []*TableRow
+	for _, row := range dataRows(t)[len(t.Header()):] {
+		if !row.Rule {
+			body = append(body, row)
+			continue
+		}
+		if len(body) > 0 {
+			bodies = append(bodies, body)
+		}
+		body = nil
+	}
+	if len(body) > 0 {
+		bodies = append(bodies, body)
+	}
+	return bodies
+}
+
+// dataRows returns the rows of t without its special rows.
+func dataRows(t *Table) []*TableRow {
+	var rows []*TableRow
+	for _, row := range t.Rows {
+		if !isSpecialRow(row) {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func cellText(cell *TableCell) string {
+	return strings.TrimSpace(orgString(cell.Children))
+}
@@ -0,0 +1,89 @@
+package goorgeous
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableColumns(t *testing.T) {
+	testCases := map[string]struct {
+		in       string
+		expected []TableColumn
+	}{
+		"numbers": {
+			"| name | n   | %   |\n|------+-----+-----|\n| a    | 1.5 | 10% |\n| b    | -2  | x   |\n| c    |     | y   |\n",
+			[]TableColumn{{}, {Align: AlignRight}, {}},
+		},
+		"cookies": {
+			"| <c> | <l6> | <10> |\n| a   | 1    | 2    |\n",
+			[]TableColumn{{Align: AlignCenter}, {Width: 6}, {Align: AlignRight, Width: 10}},
+		},
+		"column-groups": {
+			"| / | <> | < |   | > |\n| x | a  | b | c | d |\n",
+			[]TableColumn{{GroupStart: true}, {GroupStart: true}, {GroupStart: true}, {}, {}},
+		},
+		"not-cookies": {
+			"| <> | <b> |\n| <r> | x |\n",
+			[]TableColumn{{}, {}},
+		},
+	}
+
+	for caseName, tc := range testCases {
+		doc, _ := Parse([]byte(tc.in))
+		table := doc.Children[0].(*Section).Children[0].(*Table)
+		if columns := table.Columns(); !reflect.DeepEqual(columns, tc.expected) {
+			t.Errorf("case %s: Columns() of %q = %+v\nwants: %+v", caseName, tc.in, columns, tc.expected)
+		}
+	}
+}
+
+func TestTableRowGroups(t *testing.T) {
+	testCases := map[string]struct {
+		in     string
+		header []string
+		bodies [][]string
+	}{
+		"header-rows": {
+			"| a |\n| <l> |\n| b |\n|---|\n| c |\n|---|\n|---|\n| d |\n| e |\n|---|\n",
+			[]string{"a", "b"},
+			[][]string{{"c"}, {"d", "e"}},
+		},
+		"leading-rule": {
+			"|---|\n| a |\n|---|\n| b |\n",
+			nil,
+			[][]string{{"a"}, {"b"}},
+		},
+		"no-rules": {
+			"| a |\n| b |\n",
+			nil,
+			[][]string{{"a", "b"}},
+		},
+		"header-only": {
+			"| a |\n|---|\n",
+			[]string{"a"},
+			nil,
+		},
+	}
+
+	firstCells := func(rows []*TableRow) []string {
+		var cells []string
+		for _, row := range rows {
+			cells = append(cells, cellText(row.Cells[0]))
+		}
+		return cells
+	}
+	for caseName, tc := range testCases {
+		doc, _ := Parse([]byte(tc.in))
+		table := doc.Children[0].(*Section).Children[0].(*Table)
+		if header := firstCells(table.Header()); !reflect.DeepEqual(header, tc.header) {
+			t.Errorf("case %s: Header() of %q = %q\nwants: %q", caseName, tc.in, header, tc.header)
+		}
+		var bodies [][]string
+		for _, body := range table.Bodies() {
+			bodies = append(bodies, firstCells(body))
+		}
+		if !reflect.DeepEqual(bodies, tc.bodies) {
+			t.Errorf("case %s: Bodies() of %q = %q\nwants: %q", caseName, tc.in, bodies, tc.bodies)
+		}
+	}
+}
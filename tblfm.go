@@ -0,0 +1,674 @@
+package goorgeous
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// isTableFormula reports whether data is a #+TBLFM line, which holds the
+// formulas of the table right above it.
+func isTableFormula(data []byte) bool {
+	return IsKeyword(data) && strings.EqualFold(parseKeyword(data).Key, "TBLFM")
+}
+
+// RecalculateTables recalculates every table of d that has formulas. It
+// returns the first error any of them returned.
+func (d *Document) RecalculateTables() error {
+	var first error
+	Walk(d, func(n Node) bool {
+		if t, ok := n.(*Table); ok && len(t.Formulas) > 0 {
+			if err := t.Recalculate(); err != nil && first == nil {
+				first = err
+			}
+		}
+		return true
+	})
+	return first
+}
+
+// Recalculate evaluates the #+TBLFM formulas of t and stores their results in
+// its cells, like C-c * does in org.
+//
+// Column formulas like $4=$2*$3 are evaluated row by row for every row below
+// the header, then field formulas like @>$4=vsum(@2..@-1) and range formulas
+// like @2$1..@3$2=0 overwrite the fields they name. References, ranges and
+// hline references work like org's, and the calc functions vsum, vmean,
+// vmax, vmin, vcount, vprod, min, max, abs and sqrt are available. A formula
+// can end with a mode string such as ;%.2f, a printf format for its result
+// with a width and precision of up to two digits, or ;N, to read fields that
+// aren't numbers as zero.
+//
+// Fields whose formula fails or yields no finite number get #ERROR, and the
+// first failure is returned.
+// Lisp formulas, remote references and named fields aren't supported.
+func (t *Table) Recalculate() error {
+	c := newCalc(t)
+	var columns, fields []*calcFormula
+	var first error
+	for _, line := range t.Formulas {
+		for _, text := range strings.Split(line, "::") {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			f, err := parseCalcFormula(text)
+			switch {
+			case err != nil:
+				if first == nil {
+					first = err
+				}
+			case f.column:
+				columns = append(columns, f)
+			default:
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	for r := c.header + 1; r <= len(c.rows); r++ {
+		if isSpecialRow(c.rows[r-1]) {
+			continue
+		}
+		for _, f := range columns {
+			if err := c.assign(f, r, 0); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	for _, f := range fields {
+		if err := c.assign(f, 0, 0); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// calcFormula is one formula of a #+TBLFM line.
+type calcFormula struct {
+	text     string
+	lhs      calcRange
+	rhs      string
+	column   bool
+	format   string
+	numbers  bool
+	keepNull bool
+}
+
+// calcRange is a reference, or a range when to is set.
+type calcRange struct {
+	from calcRef
+	to   *calcRef
+}
+
+// calcRef is a field reference like @2$3, $-1, @>, @-I$2 or @I+1. Parts that
+// are left out refer to the field being computed.
+type calcRef struct {
+	row, col string
+}
+
+var reCalcRef = regexp.MustCompile(`^(@(?:<+|>+|[-+]?I+(?:[-+][0-9]+)?|[-+]?[0-9]+|#))?(\$(?:<+|>+|[-+]?[0-9]+|#))?`)
+var reCalcFormat = regexp.MustCompile(`^%[-+ 0#]*[0-9]{0,2}(?:\.[0-9]{1,2})?[dfeEgG]$`)
+
+func parseCalcFormula(text string) (*calcFormula, error) {
+	f := &calcFormula{text: strings.TrimSpace(text)}
+	eq := strings.IndexByte(f.text, '=')
+	if eq < 0 {
+		return nil, fmt.Errorf("goorgeous: #+TBLFM %s: no =", f.text)
+	}
+	lhs := strings.TrimSpace(f.text[:eq])
+	f.rhs = strings.TrimSpace(f.text[eq+1:])
+	if i := strings.LastIndexByte(f.rhs, ';'); i >= 0 {
+		mode := strings.TrimSpace(f.rhs[i+1:])
+		f.rhs = strings.TrimSpace(f.rhs[:i])
+		if j := strings.IndexByte(mode, '%'); j >= 0 {
+			mode, f.format = mode[:j], mode[j:]
+			if !reCalcFormat.MatchString(f.format) {
+				return nil, fmt.Errorf("goorgeous: #+TBLFM %s: unsupported format %q", f.text, f.format)
+			}
+		}
+		f.numbers = strings.Contains(mode, "N")
+		f.keepNull = strings.Contains(mode, "E")
+	}
+
+	lhsRange, n := parseCalcRange(lhs)
+	if n == 0 || n < len(lhs) {
+		return nil, fmt.Errorf("goorgeous: #+TBLFM %s: bad field %q", f.text, lhs)
+	}
+	f.lhs = lhsRange
+	f.column = lhsRange.to == nil && lhsRange.from.row == "" && lhsRange.from.col != ""
+	if strings.HasPrefix(f.rhs, "'(") {
+		return nil, fmt.Errorf("goorgeous: #+TBLFM %s: lisp formulas aren't supported", f.text)
+	}
+	return f, nil
+}
+
+// parseCalcRange parses the reference or range at the start of text and
+// returns the number of bytes it took.
+func parseCalcRange(text string) (calcRange, int) {
+	from, n := parseCalcRef(text)
+	if n == 0 || !strings.HasPrefix(text[n:], "..") {
+		return calcRange{from: from}, n
+	}
+	to, m := parseCalcRef(text[n+2:])
+	if m == 0 {
+		return calcRange{from: from}, n
+	}
+	return calcRange{from: from, to: &to}, n + 2 + m
+}
+
+func parseCalcRef(text string) (calcRef, int) {
+	m := reCalcRef.FindStringSubmatch(text)
+	return calcRef{row: strings.TrimPrefix(m[1], "@"), col: strings.TrimPrefix(m[2], "$")}, len(m[0])
+}
+
+// calc evaluates formulas over the rows of a table.
+type calc struct {
+	// rows holds the rows that aren't rules; formulas number them from 1
+	rows []*TableRow
+	// hlines holds the number of rows above each rule
+	hlines []int
+	// header is the number of rows above the first rule
+	header  int
+	columns int
+
+	// row and col are the field being computed
+	row, col int
+	formula  *calcFormula
+}
+
+func newCalc(t *Table) *calc {
+	c := &calc{columns: tableColumns(t)}
+	for _, row := range t.Rows {
+		if row.Rule {
+			c.hlines = append(c.hlines, len(c.rows))
+			continue
+		}
+		c.rows = append(c.rows, row)
+	}
+	if len(c.hlines) > 0 && c.hlines[0] > 0 {
+		c.header = c.hlines[0]
+	}
+	return c
+}
+
+// assign evaluates f for the fields it assigns to, in row r for column
+// formulas.
+func (c *calc) assign(f *calcFormula, r, col int) error {
+	c.formula, c.row, c.col = f, r, col
+	var targets [][2]int
+	if f.column {
+		col, err := c.column(f.lhs.from.col)
+		if err != nil {
+			return err
+		}
+		targets = [][2]int{{r, col}}
+	} else {
+		fields, err := c.fields(f.lhs)
+		if err != nil {
+			return err
+		}
+		targets = fields
+	}
+
+	var first error
+	for _, field := range targets {
+		c.row, c.col = field[0], field[1]
+		text, err := c.evaluate()
+		if err != nil {
+			text = "#ERROR"
+			if first == nil {
+				first = err
+			}
+		}
+		c.set(field[0], field[1], text)
+	}
+	return first
+}
+
+// evaluate computes the formula for the current field.
+func (c *calc) evaluate() (string, error) {
+	e := &calcParser{calc: c, text: c.formula.rhs}
+	v, err := e.expression()
+	if err == nil && e.skip() < len(e.text) {
+		err = c.errorf("unexpected %q", e.text[e.pos:])
+	}
+	if err != nil {
+		return "", err
+	}
+	if len(v.values) != 1 {
+		return "", c.errorf("result isn't a single number")
+	}
+
+	x := v.values[0]
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return "", c.errorf("result isn't a finite number")
+	}
+	switch format := c.formula.format; {
+	case format == "":
+		return calcString(x), nil
+	case strings.HasSuffix(format, "d"):
+		return fmt.Sprintf(format, int64(math.Round(x))), nil
+	default:
+		return fmt.Sprintf(format, x), nil
+	}
+}
+
+func (c *calc) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("goorgeous: #+TBLFM %s: @%d$%d: %s", c.formula.text, c.row, c.col, fmt.Sprintf(format, args...))
+}
+
+func (c *calc) set(r, col int, text string) {
+	row := c.rows[r-1]
+	for len(row.Cells) < col {
+		row.Cells = append(row.Cells, new(TableCell))
+	}
+	row.Cells[col-1].Children = []Node{&Text{Value: text}}
+}
+
+// fields returns the row and column of every field in rng, row by row.
+func (c *calc) fields(rng calcRange) ([][2]int, error) {
+	r1, err := c.rowOf(rng.from.row, false)
+	if err != nil {
+		return nil, err
+	}
+	c1, err := c.column(rng.from.col)
+	if err != nil {
+		return nil, err
+	}
+	if rng.to == nil {
+		return [][2]int{{r1, c1}}, nil
+	}
+
+	r2, err := c.rowOf(rng.to.row, true)
+	if err != nil {
+		return nil, err
+	}
+	c2, err := c.column(rng.to.col)
+	if err != nil {
+		return nil, err
+	}
+	if r1 > r2 {
+		r1, r2 = r2, r1
+	}
+	if c1 > c2 {
+		c1, c2 = c2, c1
+	}
+	var fields [][2]int
+	for r := r1; r <= r2; r++ {
+		if isSpecialRow(c.rows[r-1]) {
+			continue
+		}
+		for col := c1; col <= c2; col++ {
+			fields = append(fields, [2]int{r, col})
+		}
+	}
+	return fields, nil
+}
+
+// rowOf resolves the row part of a reference. A reference to a rule means the
+// row below it, or the row above it for the end of a range.
+func (c *calc) rowOf(ref string, end bool) (int, error) {
+	r := c.row
+	switch {
+	case ref == "" || ref == "0" || ref == "#":
+	case strings.HasPrefix(ref, "<"):
+		r = len(ref)
+	case strings.HasPrefix(ref, ">"):
+		r = len(c.rows) - len(ref) + 1
+	case strings.Contains(ref, "I"):
+		rel := ""
+		if ref[0] == '-' || ref[0] == '+' {
+			rel, ref = ref[:1], ref[1:]
+		}
+		count := len(ref) - len(strings.TrimLeft(ref, "I"))
+		offset, _ := strconv.Atoi(ref[count:])
+
+		hline := -1
+		switch rel {
+		case "":
+			hline = count - 1
+		case "-":
+			for i := len(c.hlines) - 1; i >= 0 && count > 0; i-- {
+				if c.hlines[i] < c.row {
+					hline, count = i, count-1
+				}
+			}
+		case "+":
+			for i := 0; i < len(c.hlines) && count > 0; i++ {
+				if c.hlines[i] >= c.row {
+					hline, count = i, count-1
+				}
+			}
+		}
+		if hline < 0 || hline >= len(c.hlines) || count > 0 && rel != "" {
+			return 0, c.errorf("no hline @%s%s", rel, ref)
+		}
+		switch r = c.hlines[hline]; {
+		case offset > 0:
+			r += offset
+		case offset < 0:
+			r += offset + 1
+		case !end:
+			r++
+		}
+	case ref[0] == '-' || ref[0] == '+':
+		n, _ := strconv.Atoi(ref)
+		r += n
+	default:
+		r, _ = strconv.Atoi(ref)
+	}
+	if r < 1 || r > len(c.rows) {
+		return 0, c.errorf("row @%s is outside the table", ref)
+	}
+	return r, nil
+}
+
+// column resolves the column part of a reference.
+func (c *calc) column(ref string) (int, error) {
+	col := c.col
+	switch {
+	case ref == "" || ref == "0" || ref == "#":
+	case strings.HasPrefix(ref, "<"):
+		col = len(ref)
+	case strings.HasPrefix(ref, ">"):
+		col = c.columns - len(ref) + 1
+	case ref[0] == '-' || ref[0] == '+':
+		n, _ := strconv.Atoi(ref)
+		col += n
+	default:
+		col, _ = strconv.Atoi(ref)
+	}
+	if col < 1 || col > c.columns {
+		return 0, c.errorf("column $%s is outside the table", ref)
+	}
+	return col, nil
+}
+
+// value returns the numbers of the fields in rng. Single empty fields are
+// zero, and empty fields in a range are left out unless the E mode is set.
+func (c *calc) value(rng calcRange) (calcValue, error) {
+	if rng.to == nil && rng.from.row == "#" && rng.from.col == "" {
+		return calcValue{values: []float64{float64(c.row)}}, nil
+	}
+	if rng.to == nil && rng.from.col == "#" && rng.from.row == "" {
+		return calcValue{values: []float64{float64(c.col)}}, nil
+	}
+
+	fields, err := c.fields(rng)
+	if err != nil {
+		return calcValue{}, err
+	}
+	v := calcValue{vector: rng.to != nil}
+	for _, field := range fields {
+		text := ""
+		if row := c.rows[field[0]-1]; field[1] <= len(row.Cells) {
+			text = cellText(row.Cells[field[1]-1])
+		}
+		if text == "" {
+			if !v.vector || c.formula.keepNull {
+				v.values = append(v.values, 0)
+			}
+			continue
+		}
+		x, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			if !c.formula.numbers {
+				return calcValue{}, c.errorf("@%d$%d isn't a number: %q", field[0], field[1], text)
+			}
+			x = 0
+		}
+		v.values = append(v.values, x)
+	}
+	return v, nil
+}
+
+// calcValue is a number, or a vector of them for ranges.
+type calcValue struct {
+	values []float64
+	vector bool
+}
+
+// calcParser evaluates the right hand side of a formula as it parses it.
+type calcParser struct {
+	calc *calc
+	text string
+	pos  int
+}
+
+var calcFunctions = map[string]func([]float64) (float64, bool){
+	"vsum": func(xs []float64) (float64, bool) {
+		sum := 0.0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum, true
+	},
+	"vmean": func(xs []float64) (float64, bool) {
+		if len(xs) == 0 {
+			return 0, false
+		}
+		sum := 0.0
+		for _, x := range xs {
+			sum += x
+		}
+		return sum / float64(len(xs)), true
+	},
+	"vmax": func(xs []float64) (float64, bool) {
+		if len(xs) == 0 {
+			return 0, false
+		}
+		max := xs[0]
+		for _, x := range xs[1:] {
+			max = math.Max(max, x)
+		}
+		return max, true
+	},
+	"vmin": func(xs []float64) (float64, bool) {
+		if len(xs) == 0 {
+			return 0, false
+		}
+		min := xs[0]
+		for _, x := range xs[1:] {
+			min = math.Min(min, x)
+		}
+		return min, true
+	},
+	"vcount": func(xs []float64) (float64, bool) {
+		return float64(len(xs)), true
+	},
+	"vprod": func(xs []float64) (float64, bool) {
+		prod := 1.0
+		for _, x := range xs {
+			prod *= x
+		}
+		return prod, true
+	},
+	"abs": func(xs []float64) (float64, bool) {
+		if len(xs) != 1 {
+			return 0, false
+		}
+		return math.Abs(xs[0]), true
+	},
+	"sqrt": func(xs []float64) (float64, bool) {
+		if len(xs) != 1 || xs[0] < 0 {
+			return 0, false
+		}
+		return math.Sqrt(xs[0]), true
+	},
+}
+
+func init() {
+	calcFunctions["max"] = calcFunctions["vmax"]
+	calcFunctions["min"] = calcFunctions["vmin"]
+}
+
+// skip skips spaces and returns the position of the next token.
+func (e *calcParser) skip() int {
+	for e.pos < len(e.text) && (e.text[e.pos] == ' ' || e.text[e.pos] == '\t') {
+		e.pos++
+	}
+	return e.pos
+}
+
+// accept consumes op if it is the next token.
+func (e *calcParser) accept(op byte) bool {
+	if e.skip() < len(e.text) && e.text[e.pos] == op {
+		e.pos++
+		return true
+	}
+	return false
+}
+
+// expression parses sums and differences.
+func (e *calcParser) expression() (calcValue, error) {
+	v, err := e.term()
+	for err == nil {
+		var op byte
+		switch {
+		case e.accept('+'):
+			op = '+'
+		case e.accept('-'):
+			op = '-'
+		default:
+			return v, nil
+		}
+		var w calcValue
+		if w, err = e.term(); err == nil {
+			v, err = e.arithmetic(op, v, w)
+		}
+	}
+	return v, err
+}
+
+// term parses products and quotients.
+func (e *calcParser) term() (calcValue, error) {
+	v, err := e.unary()
+	for err == nil {
+		var op byte
+		switch {
+		case e.accept('*'):
+			op = '*'
+		case e.accept('/'):
+			op = '/'
+		default:
+			return v, nil
+		}
+		var w calcValue
+		if w, err = e.unary(); err == nil {
+			v, err = e.arithmetic(op, v, w)
+		}
+	}
+	return v, err
+}
+
+func (e *calcParser) unary() (calcValue, error) {
+	if e.accept('-') {
+		v, err := e.unary()
+		if err != nil {
+			return v, err
+		}
+		return e.arithmetic('-', calcValue{values: []float64{0}}, v)
+	}
+	v, err := e.primary()
+	if err == nil && e.accept('^') {
+		var w calcValue
+		if w, err = e.unary(); err == nil {
+			v, err = e.arithmetic('^', v, w)
+		}
+	}
+	return v, err
+}
+
+func (e *calcParser) primary() (calcValue, error) {
+	start := e.skip()
+	if start == len(e.text) {
+		return calcValue{}, e.calc.errorf("incomplete formula")
+	}
+
+	switch ch := e.text[start]; {
+	case ch == '(':
+		e.pos++
+		v, err := e.expression()
+		if err == nil && !e.accept(')') {
+			err = e.calc.errorf("missing )")
+		}
+		return v, err
+	case ch == '@' || ch == '$':
+		rng, n := parseCalcRange(e.text[start:])
+		if n == 0 {
+			return calcValue{}, e.calc.errorf("bad reference %q", e.text[start:])
+		}
+		e.pos += n
+		return e.calc.value(rng)
+	case ch >= '0' && ch <= '9' || ch == '.':
+		for e.pos < len(e.text) && strings.IndexByte("0123456789.eE", e.text[e.pos]) >= 0 {
+			e.pos++
+		}
+		x, err := strconv.ParseFloat(e.text[start:e.pos], 64)
+		if err != nil {
+			return calcValue{}, e.calc.errorf("bad number %q", e.text[start:e.pos])
+		}
+		return calcValue{values: []float64{x}}, nil
+	case ch >= 'a' && ch <= 'z':
+		for e.pos < len(e.text) && e.text[e.pos] >= 'a' && e.text[e.pos] <= 'z' {
+			e.pos++
+		}
+		name := e.text[start:e.pos]
+		fn, ok := calcFunctions[name]
+		if !ok || !e.accept('(') {
+			return calcValue{}, e.calc.errorf("unknown function %s", name)
+		}
+		var args []float64
+		for !e.accept(')') {
+			if len(args) > 0 && !e.accept(',') {
+				return calcValue{}, e.calc.errorf("missing ) after %s arguments", name)
+			}
+			v, err := e.expression()
+			if err != nil {
+				return v, err
+			}
+			args = append(args, v.values...)
+		}
+		x, ok := fn(args)
+		if !ok {
+			return calcValue{}, e.calc.errorf("bad arguments for %s", name)
+		}
+		return calcValue{values: []float64{x}}, nil
+	}
+	return calcValue{}, e.calc.errorf("unexpected %q", e.text[start:])
+}
+
+func (e *calcParser) arithmetic(op byte, v, w calcValue) (calcValue, error) {
+	if len(v.values) != 1 || len(w.values) != 1 {
+		return calcValue{}, e.calc.errorf("%c needs single numbers, not ranges", op)
+	}
+	x, y := v.values[0], w.values[0]
+	switch op {
+	case '+':
+		x += y
+	case '-':
+		x -= y
+	case '*':
+		x *= y
+	case '/':
+		if y == 0 {
+			return calcValue{}, e.calc.errorf("division by zero")
+		}
+		x /= y
+	case '^':
+		x = math.Pow(x, y)
+	}
+	return calcValue{values: []float64{x}}, nil
+}
+
+// calcString formats x the way calc does by default, with up to 12
+// significant digits.
+func calcString(x float64) string {
+	x, _ = strconv.ParseFloat(strconv.FormatFloat(x, 'g', 12, 64), 64)
+	if abs := math.Abs(x); abs != 0 && (abs >= 1e12 || abs < 1e-5) {
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
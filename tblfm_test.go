@@ -0,0 +1,67 @@
+package goorgeous
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecalculate(t *testing.T) {
+	testCases := map[string]testCase{
+		"budget": {
+			"| Item | Qty | Price | Total |\n|------+-----+-------+-------|\n| a    |   2 |  1.25 |     0 |\n| b    |   3 |  0.5  |       |\n|------+-----+-------+-------|\n| sum  |     |       |   99  |\n#+TBLFM: $4=$2*$3;%.2f::@>$4=vsum(@2..@-1);%.2f\n",
+			"| Item | Qty | Price | Total |\n|------+-----+-------+-------|\n| a    | 2   | 1.25  | 2.50  |\n| b    | 3   | 0.5   | 1.50  |\n|------+-----+-------+-------|\n| sum  |     |       | 4.00  |\n#+TBLFM: $4=$2*$3;%.2f::@>$4=vsum(@2..@-1);%.2f\n",
+		},
+		"hline-references": {
+			"| x | y |\n|---+---|\n| 1 |   |\n| 2 |   |\n|---+---|\n| 4 |   |\n| 6 |   |\n|---+---|\n|   |   |\n#+tblfm: $2=$1^2\n#+TBLFM: @>$1=vmean(@II..@III)::@>$2=vmax(@I..@>>)\n",
+			"| x | y |\n|---+---|\n| 1 | 1 |\n| 2 | 4 |\n|---+---|\n| 4 | 16 |\n| 6 | 36 |\n|---+---|\n| 5 | 36 |\n#+tblfm: $2=$1^2\n#+TBLFM: @>$1=vmean(@II..@III)::@>$2=vmax(@I..@>>)\n",
+		},
+		"relative-references": {
+			"| 1 |   |   |\n| 2 |   |   |\n| 3 |   |   |\n#+TBLFM: $2=$-1*10::@2$3..@>$3=@-1$1+$1::@1$3=@#+$#;%d\n",
+			"| 1 | 10 | 4 |\n| 2 | 20 | 3 |\n| 3 | 30 | 5 |\n#+TBLFM: $2=$-1*10::@2$3..@>$3=@-1$1+$1::@1$3=@#+$#;%d\n",
+		},
+		"functions": {
+			"| 4 | -9 |   |   |   |   |\n#+TBLFM: $3=vmin($1..$2)::$4=abs($2)+sqrt($1)::$5=max(1, (2 - 5) * 2, $1)::$6=vcount($1..$5)/3;%.3f\n",
+			"| 4 | -9 | -9 | 11 | 4 | 1.667 |\n#+TBLFM: $3=vmin($1..$2)::$4=abs($2)+sqrt($1)::$5=max(1, (2 - 5) * 2, $1)::$6=vcount($1..$5)/3;%.3f\n",
+		},
+		"empty-and-text-fields": {
+			"| a | 1 |   |   |\n| 2 |   |   |   |\n#+TBLFM: $3=vsum(@1$1..@2$2);N::$4=$2+1\n",
+			"| a | 1 | 3 | 2 |\n| 2 |   | 3 | 1 |\n#+TBLFM: $3=vsum(@1$1..@2$2);N::$4=$2+1\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		doc, _ := Parse([]byte(tc.in))
+		if err := doc.RecalculateTables(); err != nil {
+			t.Errorf("case %s: RecalculateTables() on %q returned error: %s", caseName, tc.in, err)
+		}
+		var out bytes.Buffer
+		Write(&out, doc)
+		if out.String() != tc.expected {
+			t.Errorf("case %s: RecalculateTables() on %q = %q\nwants: %q", caseName, tc.in, out.String(), tc.expected)
+		}
+	}
+}
+
+func TestRecalculateErrors(t *testing.T) {
+	testCases := map[string]testCase{
+		"text-field":       {"| a | |\n#+TBLFM: $2=$1*2\n", "| a | #ERROR |\n#+TBLFM: $2=$1*2\n"},
+		"division-by-zero": {"| 1 | |\n#+TBLFM: $2=$1/0\n", "| 1 | #ERROR |\n#+TBLFM: $2=$1/0\n"},
+		"outside-table":    {"| 1 | |\n#+TBLFM: $2=@2$1\n", "| 1 | #ERROR |\n#+TBLFM: $2=@2$1\n"},
+		"unknown-function": {"| 1 | |\n#+TBLFM: $2=vmedian($1)\n", "| 1 | #ERROR |\n#+TBLFM: $2=vmedian($1)\n"},
+		"overflow":         {"| 10 | |\n#+TBLFM: $2=$1^1000000\n", "| 10 | #ERROR |\n#+TBLFM: $2=$1^1000000\n"},
+		"format-width":     {"| 1 | 2 |\n#+TBLFM: $2=$1;%99999999d\n", "| 1 | 2 |\n#+TBLFM: $2=$1;%99999999d\n"},
+		"lisp":             {"| 1 | 2 |\n#+TBLFM: $2='(+ $1 1)\n", "| 1 | 2 |\n#+TBLFM: $2='(+ $1 1)\n"},
+	}
+
+	for caseName, tc := range testCases {
+		doc, _ := Parse([]byte(tc.in))
+		if err := doc.RecalculateTables(); err == nil {
+			t.Errorf("case %s: RecalculateTables() on %q returned no error", caseName, tc.in)
+		}
+		var out bytes.Buffer
+		Write(&out, doc)
+		if out.String() != tc.expected {
+			t.Errorf("case %s: RecalculateTables() on %q = %q\nwants: %q", caseName, tc.in, out.String(), tc.expected)
+		}
+	}
+}
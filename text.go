@@ -0,0 +1,153 @@
+package goorgeous
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// PlainTextOptions configures a PlainTextRenderer.
+type PlainTextOptions struct {
+	// OmitSource drops source blocks, example blocks and fixed width areas,
+	// which mostly hold code rather than prose.
+	OmitSource bool
+}
+
+// PlainTextRenderer renders a Document as readable text without any markup,
+// for summaries, search indexes and word counts.
+//
+// Every headline, paragraph, list item and table row is written on a line of
+// its own, with blank lines between elements. Emphasis markers are removed,
+// links are replaced by their description (or URL) and footnote references
+// are dropped. Planning lines, keywords, comments, drawers and export blocks
+// are skipped.
+type PlainTextRenderer struct {
+	PlainTextOptions
+}
+
+// NewPlainTextRenderer returns a PlainTextRenderer using opts.
+func NewPlainTextRenderer(opts PlainTextOptions) *PlainTextRenderer {
+	return &PlainTextRenderer{PlainTextOptions: opts}
+}
+
+// PlainText is the easiest way to turn a byte slice of org content into plain text.
+func PlainText(input []byte, opts PlainTextOptions) []byte {
+	var out bytes.Buffer
+	doc, _ := Parse(input)
+	NewPlainTextRenderer(opts).Render(&out, doc)
+	return out.Bytes()
+}
+
+// Render writes doc to w as plain text.
+func (r *PlainTextRenderer) Render(w io.Writer, doc *Document) error {
+	_, err := io.WriteString(w, r.blocks(doc.Children))
+	return err
+}
+
+// blocks returns the text for a run of elements, separated by blank lines.
+func (r *PlainTextRenderer) blocks(nodes []Node) string {
+	var blocks []string
+	for _, n := range nodes {
+		blocks = append(blocks, r.element(n))
+	}
+	return joinBlocks(blocks)
+}
+
+func (r *PlainTextRenderer) element(n Node) string {
+	switch n := n.(type) {
+	case *Section:
+		return r.blocks(n.Children)
+	case *Headline:
+		return joinBlocks([]string{textLine(plainText(n.Title)), r.blocks(n.Children)})
+	case *Paragraph:
+		return textLine(plainText(n.Children))
+	case *FootnoteDefinition:
+		return r.blocks(n.Children)
+	case *List:
+		var out bytes.Buffer
+		for _, item := range n.Items {
+			text := plainText(item.Term)
+			if item.Checkbox != "" {
+				text = "[" + item.Checkbox + "] " + text
+			}
+			first, rest := listItemBody(item)
+			for _, para := range first {
+				text += " " + plainText(para.(*Paragraph).Children)
+			}
+			out.WriteString(textLine(text))
+			for _, child := range rest {
+				out.WriteString(r.element(child))
+			}
+		}
+		return out.String()
+	case *Table:
+		var out bytes.Buffer
+		for _, row := range dataRows(n) {
+			cells := make([]string, len(row.Cells))
+			for i, cell := range row.Cells {
+				cells[i] = plainText(cell.Children)
+			}
+			out.WriteString(textLine(strings.Join(cells, " ")))
+		}
+		return out.String()
+	case *Block:
+		return r.block(n)
+	case *FixedWidth:
+		if r.OmitSource {
+			return ""
+		}
+		return strings.Join(n.Lines, "\n") + "\n"
+	}
+	return ""
+}
+
+func (r *PlainTextRenderer) block(b *Block) string {
+	switch strings.ToUpper(b.Name) {
+	case "QUOTE", "CENTER":
+		return r.blocks(b.Children)
+	case "SRC", "EXAMPLE":
+		if r.OmitSource || len(b.Lines) == 0 {
+			return ""
+		}
+		return strings.Join(b.Lines, "\n") + "\n"
+	case "VERSE":
+		return joinBlocks([]string{strings.Join(b.Lines, "\n")})
+	}
+	return ""
+}
+
+// textLine returns text on a single line, with runs of whitespace collapsed.
+func textLine(text string) string {
+	if text = strings.Join(strings.Fields(text), " "); text == "" {
+		return ""
+	}
+	return text + "\n"
+}
+
+// plainText returns the text of inline nodes without any markup.
+func plainText(nodes []Node) string {
+	var out bytes.Buffer
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *Text:
+			out.WriteString(n.Value)
+		case *Code:
+			out.WriteString(n.Value)
+		case *Emphasis:
+			out.WriteString(plainText(n.Children))
+		case *Link:
+			url, isImage := linkTarget(n)
+			switch {
+			case len(n.Description) > 0:
+				out.WriteString(plainText(n.Description))
+			case !isImage:
+				out.WriteString(url)
+			}
+		case *StatisticsCookie:
+			out.WriteString("[" + n.Value + "]")
+		case *Timestamp:
+			out.WriteString(timestampString(n))
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,47 @@
+package goorgeous
+
+import (
+	"testing"
+)
+
+func TestPlainText(t *testing.T) {
+	testCases := map[string]testCase{
+		"headlines": {
+			"#+TITLE: skipped\n* TODO [#A] a /headline/ :tag:\ntext *with*\n  =markup= &amp;[fn:1]\n** child\n",
+			"a headline\n\ntext with markup &amp;\n\nchild\n",
+		},
+		"links": {
+			"[[https://example.com][the site]] and [[https://example.com]] [[file:img.png]] [[file:img.png][a gopher]]\n",
+			"the site and https://example.com a gopher\n",
+		},
+		"lists-and-tables": {
+			"- a\n- /b/\n- term :: def\n\n| a | b |\n|---+---|\n| 1 | 2 |\n",
+			"a\nb\nterm def\n\na b\n1 2\n",
+		},
+		"nested-lists": {
+			"- a\n  1. one\n- b\n",
+			"a\none\nb\n",
+		},
+		"blocks": {
+			"#+BEGIN_SRC go\nx := 1\n#+END_SRC\n#+BEGIN_QUOTE\nquoted *text*\n#+END_QUOTE\n#+BEGIN_VERSE\none\n  two\n#+END_VERSE\n: fixed\n#+BEGIN_EXPORT html\n<br>\n#+END_EXPORT\n# comment\n-----\n",
+			"x := 1\n\nquoted text\n\none\n  two\n\nfixed\n",
+		},
+		"footnotes": {
+			"text[fn:1]\n\n[fn:1] the /note/\n",
+			"text\n\nthe note\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		out := PlainText([]byte(tc.in), PlainTextOptions{})
+		if string(out) != tc.expected {
+			t.Errorf("case %s for PlainText() from %q = %q\nwants: %q", caseName, tc.in, out, tc.expected)
+		}
+	}
+
+	in := "text\n#+BEGIN_SRC go\nx := 1\n#+END_SRC\n#+BEGIN_EXAMPLE\nexample\n#+END_EXAMPLE\n: fixed\nmore\n"
+	expected := "text\n\nmore\n"
+	if out := PlainText([]byte(in), PlainTextOptions{OmitSource: true}); string(out) != expected {
+		t.Errorf("PlainText() with OmitSource from %q = %q\nwants: %q", in, out, expected)
+	}
+}
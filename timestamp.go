@@ -0,0 +1,270 @@
+package goorgeous
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	reTimestampTime     = regexp.MustCompile(`^(\d{1,2}:\d{2})(?:-(\d{1,2}:\d{2}))?$`)
+	reTimestampRepeater = regexp.MustCompile(`^(\+|\+\+|\.\+)(\d+)([hdwmy])$`)
+	reTimestampDelay    = regexp.MustCompile(`^(--?)(\d+)([hdwmy])$`)
+)
+
+// parseTimestamp parses the timestamp at the start of data, including a
+// <a>--<b> range. It returns nil if data doesn't start with one, and the
+// length of the timestamp otherwise.
+func parseTimestamp(data []byte) (*Timestamp, int) {
+	ts, n := parseTimestampPart(data)
+	if ts == nil || !ts.End.IsZero() || !bytes.HasPrefix(data[n:], []byte("--")) {
+		return ts, n
+	}
+	end, m := parseTimestampPart(data[n+2:])
+	if end == nil || end.Active != ts.Active || !end.End.IsZero() {
+		return ts, n
+	}
+	ts.End = end.Time
+	return ts, n + 2 + m
+}
+
+// parseTimestampPart parses a single bracketed timestamp.
+func parseTimestampPart(data []byte) (*Timestamp, int) {
+	if len(data) == 0 || (data[0] != '<' && data[0] != '[') {
+		return nil, 0
+	}
+	closing := byte('>')
+	if data[0] == '[' {
+		closing = ']'
+	}
+	end := bytes.IndexByte(data, closing)
+	if end < 0 {
+		return nil, 0
+	}
+
+	fields := strings.Fields(string(data[1:end]))
+	if len(fields) == 0 {
+		return nil, 0
+	}
+	date, err := time.Parse("2006-01-02", fields[0])
+	if err != nil {
+		return nil, 0
+	}
+
+	ts := &Timestamp{Active: data[0] == '<', Time: date}
+	var ok bool
+	for _, field := range fields[1:] {
+		if m := reTimestampTime.FindStringSubmatch(field); m != nil && !ts.HasTime {
+			if ts.Time, ok = clockTime(date, m[1]); !ok {
+				return nil, 0
+			}
+			ts.HasTime = true
+			if m[2] != "" {
+				if ts.End, ok = clockTime(date, m[2]); !ok {
+					return nil, 0
+				}
+			}
+			continue
+		}
+		if m := reTimestampRepeater.FindStringSubmatch(field); m != nil && ts.Repeater == nil {
+			value, _ := strconv.Atoi(m[2])
+			ts.Repeater = &Repeater{Mark: m[1], Value: value, Unit: m[3]}
+			continue
+		}
+		if m := reTimestampDelay.FindStringSubmatch(field); m != nil && ts.Delay == nil {
+			value, _ := strconv.Atoi(m[2])
+			ts.Delay = &Delay{FirstOnly: m[1] == "--", Value: value, Unit: m[3]}
+			continue
+		}
+		// the day name, which may be in any language
+		if strings.IndexFunc(field, func(r rune) bool { return !unicode.IsLetter(r) && r != '.' }) >= 0 {
+			return nil, 0
+		}
+	}
+	return ts, end + 1
+}
+
+// clockTime returns the time of day clock, such as 9:30, on date.
+func clockTime(date time.Time, clock string) (time.Time, bool) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return date.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute), true
+}
+
+// timestampString returns the org form of a timestamp. Ranges within a day
+// are written with a time range, other ranges as two timestamps.
+func timestampString(ts *Timestamp) string {
+	open, closing := "[", "]"
+	if ts.Active {
+		open, closing = "<", ">"
+	}
+	layout := "2006-01-02 Mon"
+	if ts.HasTime {
+		layout += " 15:04"
+	}
+
+	text := ts.Time.Format(layout)
+	timeRange := !ts.End.IsZero() && ts.HasTime && sameDay(ts.Time, ts.End)
+	if timeRange {
+		text += ts.End.Format("-15:04")
+	}
+	if r := ts.Repeater; r != nil {
+		text += " " + r.Mark + strconv.Itoa(r.Value) + r.Unit
+	}
+	if d := ts.Delay; d != nil {
+		mark := "-"
+		if d.FirstOnly {
+			mark = "--"
+		}
+		text += " " + mark + strconv.Itoa(d.Value) + d.Unit
+	}
+
+	text = open + text + closing
+	if !ts.End.IsZero() && !timeRange {
+		text += "--" + open + ts.End.Format(layout) + closing
+	}
+	return text
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// Next returns the first occurrence of the timestamp at or after after: the
+// timestamp itself, or one of its repetitions if it has a repeater. It
+// reports false when there is none. Org timestamps have no time zone, so
+// after is compared by its wall clock time and the result is in UTC.
+//
+// The repetitions of all repeater marks fall on the same times; they only
+// differ in how org moves the timestamp when a task is marked done.
+func (ts *Timestamp) Next(after time.Time) (time.Time, bool) {
+	after = floating(after)
+	if !ts.Time.Before(after) {
+		return ts.Time, true
+	}
+	r := ts.Repeater
+	if r == nil || r.Value <= 0 {
+		return time.Time{}, false
+	}
+
+	// start from an estimate that is never past the answer and step forward;
+	// seconds rather than a Duration, which only spans 292 years
+	unit := int64(repeaterUnits[r.Unit] / time.Second)
+	n := int((after.Unix() - ts.Time.Unix()) / (int64(r.Value) * unit))
+	next := r.repeat(ts.Time, n)
+	for next.Before(after) {
+		n++
+		next = r.repeat(ts.Time, n)
+	}
+	return next, true
+}
+
+// floating returns the wall clock time of t in UTC, the way timestamps hold
+// their times.
+func floating(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// repeaterUnits holds the longest duration of every repeater unit.
+var repeaterUnits = map[string]time.Duration{
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"m": 31 * 24 * time.Hour,
+	"y": 366 * 24 * time.Hour,
+}
+
+// repeat returns t repeated n times.
+func (r *Repeater) repeat(t time.Time, n int) time.Time {
+	k := n * r.Value
+	switch r.Unit {
+	case "h":
+		return t.AddDate(0, 0, k/24).Add(time.Duration(k%24) * time.Hour)
+	case "d":
+		return t.AddDate(0, 0, k)
+	case "w":
+		return t.AddDate(0, 0, 7*k)
+	case "m":
+		return t.AddDate(0, k, 0)
+	case "y":
+		return t.AddDate(k, 0, 0)
+	}
+	return t
+}
+
+// parsePlanning parses a planning line such as
+//
+//	DEADLINE: <2024-01-05 Fri> SCHEDULED: <2024-01-02 Tue>
+//
+// returning nil if data isn't one.
+func parsePlanning(data []byte) *Planning {
+	rest := bytes.TrimSpace(data)
+	if len(rest) == 0 {
+		return nil
+	}
+
+	planning := new(Planning)
+	for len(rest) > 0 {
+		colon := bytes.IndexByte(rest, ':')
+		if colon < 0 {
+			return nil
+		}
+		keyword := string(rest[:colon])
+		rest = bytes.TrimLeft(rest[colon+1:], " \t")
+		ts, n := parseTimestamp(rest)
+		if ts == nil {
+			return nil
+		}
+		switch keyword {
+		case "SCHEDULED":
+			planning.Scheduled = ts
+		case "DEADLINE":
+			planning.Deadline = ts
+		case "CLOSED":
+			planning.Closed = ts
+		default:
+			return nil
+		}
+		rest = bytes.TrimLeft(rest[n:], " \t")
+	}
+	return planning
+}
+
+// planningEntry is a keyword and its timestamp on a planning line.
+type planningEntry struct {
+	keyword string
+	ts      *Timestamp
+}
+
+// planningEntries returns the timestamps of a planning line in the order org
+// writes them.
+func planningEntries(p *Planning) []planningEntry {
+	var entries []planningEntry
+	for _, entry := range []planningEntry{
+		{"CLOSED", p.Closed},
+		{"DEADLINE", p.Deadline},
+		{"SCHEDULED", p.Scheduled},
+	} {
+		if entry.ts != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// planningString returns the org form of a planning line, without its
+// trailing newline.
+func planningString(p *Planning) string {
+	var parts []string
+	for _, entry := range planningEntries(p) {
+		parts = append(parts, entry.keyword+": "+timestampString(entry.ts))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,38 @@
+package goorgeous
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampNext(t *testing.T) {
+	date := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	testCases := map[string]struct {
+		in       string
+		after    time.Time
+		expected time.Time
+		ok       bool
+	}{
+		"upcoming":         {"<2024-01-02 Tue>", date(2024, 1, 1, 0), date(2024, 1, 2, 0), true},
+		"same-time":        {"<2024-01-02 Tue 10:00>", date(2024, 1, 2, 10), date(2024, 1, 2, 10), true},
+		"past":             {"<2024-01-02 Tue>", date(2024, 1, 3, 0), time.Time{}, false},
+		"hourly":           {"<2024-01-02 Tue 10:00 +3h>", date(2024, 1, 3, 0), date(2024, 1, 3, 1), true},
+		"weekly":           {"<2024-01-02 Tue +1w>", date(2024, 3, 1, 0), date(2024, 3, 5, 0), true},
+		"catch-up":         {"<2024-01-02 Tue ++2d>", date(2024, 1, 5, 12), date(2024, 1, 6, 0), true},
+		"monthly":          {"[2024-01-15 Mon .+1m]", date(2025, 6, 16, 0), date(2025, 7, 15, 0), true},
+		"yearly":           {"<2000-02-29 Tue +1y>", date(2024, 1, 1, 0), date(2024, 2, 29, 0), true},
+		"distant-past":     {"<1700-01-01 Fri 10:00 +1h>", date(2024, 1, 3, 0), date(2024, 1, 3, 0), true},
+		"wall-clock-after": {"<2024-01-02 Tue 10:00>", time.Date(2024, 1, 2, 11, 0, 0, 0, time.FixedZone("", -5*3600)), time.Time{}, false},
+	}
+
+	for caseName, tc := range testCases {
+		ts, _ := parseTimestamp([]byte(tc.in))
+		next, ok := ts.Next(tc.after)
+		if !next.Equal(tc.expected) || ok != tc.ok {
+			t.Errorf("case %s: Next(%s) of %s = %s, %t\nwants: %s, %t", caseName, tc.after, tc.in, next, ok, tc.expected, tc.ok)
+		}
+	}
+}
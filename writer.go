@@ -0,0 +1,574 @@
+package goorgeous
+
+import (
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Write writes doc to w as org content.
+//
+// Nodes that haven't changed since doc was parsed are written exactly as they
+// appeared in the input, so whitespace, indentation, blank lines, tag alignment
+// and block markers survive a round trip. Changed and new nodes are written in
+// org's usual layout, keeping the indentation and tag column of the text they
+// replace.
+func Write(w io.Writer, doc *Document) error {
+	ow := &orgWriter{src: doc.src, prints: make(map[Node]string)}
+	ow.node(doc)
+	_, err := w.Write(ow.out.Bytes())
+	return err
+}
+
+// source is the text a node was parsed from. Containers also keep the lines
+// they write before (head) and after (tail) their children, so those can be
+// reused when only a child changed. The prints are the canonical form of each
+// part at parse time; a part whose canonical form still matches is unchanged.
+//
+// Headlines also keep their planning line and property drawer, which are
+// reused on their own when the headline line changed, and other elements
+// their affiliated keywords.
+type source struct {
+	raw, head, tail                string
+	rawPrint, headPrint, tailPrint string
+
+	planning, properties           string
+	planningPrint, propertiesPrint string
+
+	// affiliated holds the affiliated keyword lines of paragraphs, tables
+	// and blocks
+	affiliated, affiliatedPrint string
+}
+
+func (s *source) fingerprint(n Node, prints map[Node]string) {
+	if h, ok := n.(*Headline); ok {
+		s.planningPrint, s.propertiesPrint = planningText(h, nil), propertiesText(h, nil)
+	}
+	s.affiliatedPrint = affiliatedText(n, s)
+	s.rawPrint = (&orgWriter{prints: prints}).canonical(n)
+	s.headPrint = headText(n, s)
+	s.tailPrint = tailText(n, s)
+}
+
+type orgWriter struct {
+	out bytes.Buffer
+	src map[Node]*source
+
+	// columns is the number of columns of the table being written
+	columns int
+
+	// indent is the indentation of the contents of the list item being
+	// written, which nodes without a source are indented by
+	indent string
+
+	// prints caches the canonical form of nodes, which nested nodes need
+	// again for every node around them
+	prints map[Node]string
+}
+
+// canonical returns the org content for n without reusing any source text.
+func (w *orgWriter) canonical(n Node) string {
+	if text, ok := w.prints[n]; ok {
+		return text
+	}
+	c := &orgWriter{prints: w.prints}
+	c.node(n)
+	text := c.out.String()
+	if w.prints != nil {
+		w.prints[n] = text
+	}
+	return text
+}
+
+func (w *orgWriter) node(n Node) {
+	if w.out.Len() > 0 && !bytes.HasSuffix(w.out.Bytes(), []byte("\n")) {
+		w.out.WriteByte('\n')
+	}
+
+	s := w.src[n]
+	if s != nil && s.rawPrint == w.canonical(n) {
+		w.out.WriteString(s.raw)
+		return
+	}
+	if s == nil && w.indent != "" {
+		w.out.WriteString(indentText(w.canonical(n), w.indent))
+		return
+	}
+	if s == nil {
+		w.out.WriteString(affiliatedText(n, s))
+	} else {
+		w.part(s.affiliated, s.affiliatedPrint, affiliatedText(n, s))
+	}
+
+	switch n := n.(type) {
+	case *Document:
+		w.head(s, headText(n, s))
+		for _, child := range n.Children {
+			w.node(child)
+		}
+	case *Headline:
+		w.head(s, headText(n, s))
+		for _, child := range n.Children {
+			w.node(child)
+		}
+	case *Section:
+		w.elements(n.Children)
+	case *Block:
+		w.head(s, headText(n, s))
+		if isGreaterBlock(n.Name) {
+			w.elements(n.Children)
+		} else {
+			for _, line := range n.Lines {
+				w.out.WriteString(line + "\n")
+			}
+		}
+		w.tail(s, tailText(n, s))
+		w.blank(s)
+	case *List:
+		// new items line up with the parsed ones
+		outer := w.indent
+		for _, item := range n.Items {
+			if s := w.src[item]; s != nil {
+				w.indent = indentation(s, false)
+				break
+			}
+		}
+		for _, item := range n.Items {
+			w.node(item)
+		}
+		w.indent = outer
+		w.blank(s)
+	case *ListItem:
+		indent := indentation(s, false)
+		w.head(s, listItemLine(n, indent))
+		outer := w.indent
+		w.indent = indent + "  "
+		if len(n.Bullet) > 1 {
+			w.indent = indent + strings.Repeat(" ", len(n.Bullet)+1)
+		}
+		_, rest := listItemBody(n)
+		w.elements(rest)
+		w.indent = outer
+		w.blank(s)
+	case *Drawer:
+		if n.Lines != nil || n.Children == nil {
+			w.out.WriteString(w.leaf(n, s))
+			w.blank(s)
+			return
+		}
+		w.head(s, headText(n, s))
+		w.elements(n.Children)
+		w.tail(s, tailText(n, s))
+		w.blank(s)
+	case *Table:
+		w.columns = tableColumns(n)
+		for _, row := range n.Rows {
+			w.node(row)
+		}
+		w.tail(s, tailText(n, s))
+		w.blank(s)
+	default:
+		w.out.WriteString(w.leaf(n, s))
+		w.blank(s)
+	}
+}
+
+// elements writes the elements of a section or block. Elements that weren't
+// parsed are kept apart from their neighbours by a blank line, so they don't
+// run into them when the output is parsed again.
+func (w *orgWriter) elements(nodes []Node) {
+	for i, n := range nodes {
+		if i > 0 && (w.src[n] == nil || w.src[nodes[i-1]] == nil) && !bytes.HasSuffix(w.out.Bytes(), []byte("\n\n")) {
+			w.out.WriteByte('\n')
+		}
+		w.node(n)
+	}
+}
+
+// head writes the lines a container had before its children when they are
+// unchanged, and text followed by the blank lines that came after them otherwise.
+func (w *orgWriter) head(s *source, text string) {
+	if s == nil {
+		w.out.WriteString(text)
+		return
+	}
+	w.part(s.head, s.headPrint, text)
+}
+
+// tail is head for the lines a container had after its children.
+func (w *orgWriter) tail(s *source, text string) {
+	if s == nil {
+		w.out.WriteString(text)
+		return
+	}
+	w.part(s.tail, s.tailPrint, text)
+}
+
+func (w *orgWriter) part(raw, print, text string) {
+	if print == text {
+		w.out.WriteString(raw)
+		return
+	}
+	w.out.WriteString(text)
+	w.out.WriteString(blankSuffix(raw))
+}
+
+// blank writes the blank lines that followed an element in its source.
+func (w *orgWriter) blank(s *source) {
+	if s != nil {
+		w.out.WriteString(blankSuffix(s.raw))
+	}
+}
+
+// headText returns the canonical lines a container writes before its children.
+func headText(n Node, s *source) string {
+	switch n := n.(type) {
+	case *Headline:
+		return headlineLine(n, s)
+	case *Block:
+		begin := "#+BEGIN_" + n.Name
+		if len(n.Parameters) > 0 {
+			begin += " " + strings.Join(n.Parameters, " ")
+		}
+		return indentation(s, false) + begin + "\n"
+	case *Drawer:
+		return indentation(s, false) + ":" + n.Name + ":\n"
+	case *ListItem:
+		return listItemLine(n, indentation(s, false))
+	}
+	return ""
+}
+
+// tailText returns the canonical lines a container writes after its children.
+func tailText(n Node, s *source) string {
+	switch n := n.(type) {
+	case *Block:
+		return indentation(s, true) + "#+END_" + n.Name + "\n"
+	case *Drawer:
+		return indentation(s, true) + ":END:\n"
+	case *Table:
+		var text string
+		for _, formulas := range n.Formulas {
+			text += indentation(s, true) + "#+TBLFM: " + formulas + "\n"
+		}
+		return text
+	}
+	return ""
+}
+
+func (w *orgWriter) leaf(n Node, s *source) string {
+	switch n := n.(type) {
+	case *Paragraph:
+		return indentLines(orgString(n.Children), indentation(s, false))
+	case *TableRow:
+		return tableRowLine(n, s, w.columns)
+	case *Drawer:
+		text := ":" + n.Name + ":\n"
+		if len(n.Lines) > 0 {
+			text += strings.Join(n.Lines, "\n") + "\n"
+		}
+		return text + ":END:\n"
+	case *Clock:
+		return indentation(s, false) + clockString(n) + "\n"
+	case *FootnoteDefinition:
+		return "[fn:" + n.Label + "] " + paragraphsText(n.Children, "")
+	case *FixedWidth:
+		indent := indentation(s, false)
+		var out bytes.Buffer
+		for _, line := range n.Lines {
+			out.WriteString(indent + ":")
+			if line != "" {
+				out.WriteString(" " + line)
+			}
+			out.WriteByte('\n')
+		}
+		return out.String()
+	case *Keyword:
+		if n.Value == "" {
+			return "#+" + n.Key + ":\n"
+		}
+		return "#+" + n.Key + ": " + n.Value + "\n"
+	case *Comment:
+		return "# " + n.Value + "\n"
+	case *HorizontalRule:
+		return "-----\n"
+	}
+	return ""
+}
+
+func headlineLine(h *Headline, s *source) string {
+	line := strings.Repeat("*", h.Level) + " "
+	if h.Keyword != "" {
+		line += h.Keyword + " "
+	}
+	if h.Priority != nil {
+		line += "[#" + h.Priority.Value + "] "
+	}
+	line += orgString(h.Title)
+	if len(h.Tags) > 0 {
+		line = alignTags(line, ":"+strings.Join(h.Tags, ":")+":", s)
+	}
+	return line + "\n" + planningText(h, s) + propertiesText(h, s)
+}
+
+// planningText returns the planning line of a headline, as it was parsed if
+// it hasn't changed.
+func planningText(h *Headline, s *source) string {
+	if h.Planning == nil {
+		return ""
+	}
+	text := planningString(h.Planning) + "\n"
+	if s != nil && s.planning != "" {
+		if s.planningPrint == text {
+			return s.planning
+		}
+		text = indentation(&source{raw: s.planning}, false) + text
+	}
+	return text
+}
+
+// propertiesText is planningText for the property drawer of a headline. In a
+// changed drawer only the lines of added or changed properties are written
+// anew.
+func propertiesText(h *Headline, s *source) string {
+	if len(h.Properties) == 0 {
+		return ""
+	}
+	text := propertyDrawerString(h.Properties)
+	if s == nil || s.properties == "" {
+		return text
+	}
+	if s.propertiesPrint == text {
+		return s.properties
+	}
+	return editPropertyDrawer(s.properties, h.Properties)
+}
+
+// editPropertyDrawer returns the property drawer drawer with properties as
+// its values. Lines of unchanged properties are kept, those of changed ones
+// are replaced in place and new ones are added at the end, with the
+// indentation of the drawer.
+func editPropertyDrawer(drawer string, properties map[string]string) string {
+	lines := strings.SplitAfter(drawer, "\n")
+	end := len(lines) - 1
+	for end > 0 && strings.TrimSpace(lines[end]) == "" {
+		end--
+	}
+
+	// names holds the property each line sets, with KEY+ lines merged as
+	// parseProperties does
+	names := make([]string, len(lines))
+	values := make(map[string]string)
+	for i := 1; i < end; i++ {
+		key, value, ok := parseProperty(lines[i])
+		if !ok {
+			continue
+		}
+		if strings.HasSuffix(key, "+") {
+			for _, k := range []string{strings.TrimSuffix(key, "+"), key} {
+				if name, ok := propertyKey(values, k); ok {
+					key, value = name, joinPropertyValues(values[name], value)
+					break
+				}
+			}
+		}
+		names[i], values[key] = key, value
+	}
+
+	var out bytes.Buffer
+	out.WriteString(lines[0])
+	replaced := make(map[string]bool)
+	for i := 1; i < end; i++ {
+		name := names[i]
+		value, ok := properties[name]
+		switch {
+		case name == "", ok && value == values[name]:
+			out.WriteString(lines[i])
+		case !ok, replaced[name]:
+			// removed, or already written in place
+		default:
+			out.WriteString(indentation(&source{raw: lines[i]}, false) + propertyLine(name, value))
+			replaced[name] = true
+		}
+	}
+	indent := indentation(&source{raw: lines[0]}, false)
+	for _, name := range propertyNames(properties) {
+		if _, ok := values[name]; !ok {
+			out.WriteString(indent + propertyLine(name, properties[name]))
+		}
+	}
+	for _, line := range lines[end:] {
+		out.WriteString(line)
+	}
+	return out.String()
+}
+
+// alignTags appends tags to a headline line. If the headline had tags when it
+// was parsed, the new tags are padded to end in the same column.
+func alignTags(line, tags string, s *source) string {
+	pad := 1
+	if s != nil {
+		orig := strings.TrimRight(firstLine(s.head), " \t")
+		if origTags, _ := findTags([]byte(orig), 0); len(origTags) > 0 {
+			if width := displayWidth(orig) - displayWidth(line) - displayWidth(tags); width > pad {
+				pad = width
+			}
+		}
+	}
+	return line + strings.Repeat(" ", pad) + tags
+}
+
+// listItemLine returns the bullet line of a list item, indented by indent.
+func listItemLine(item *ListItem, indent string) string {
+	bullet := item.Bullet
+	if bullet == "" {
+		bullet = "-"
+	}
+	line := indent + bullet + " "
+	if item.Counter != "" {
+		line += "[@" + item.Counter + "] "
+	}
+	if item.Checkbox != "" {
+		line += "[" + item.Checkbox + "] "
+	}
+	if item.Term != nil {
+		line += orgString(item.Term) + " :: "
+	}
+	first, _ := listItemBody(item)
+	return line + paragraphsText(first, strings.Repeat(" ", len(line)))
+}
+
+func isList(n Node) bool {
+	_, ok := n.(*List)
+	return ok
+}
+
+// listItemBody splits the children of a list item into the paragraph on its
+// bullet line and the elements after it.
+func listItemBody(item *ListItem) ([]Node, []Node) {
+	if len(item.Children) > 0 {
+		if _, ok := item.Children[0].(*Paragraph); ok {
+			return item.Children[:1], item.Children[1:]
+		}
+	}
+	return nil, item.Children
+}
+
+func tableRowLine(row *TableRow, s *source, columns int) string {
+	if row.Rule {
+		if columns < 1 {
+			columns = 1
+		}
+		return "|" + strings.Repeat("---+", columns-1) + "---|\n"
+	}
+
+	var widths []int
+	if s != nil {
+		cells := strings.Split(strings.TrimRight(firstLine(s.raw), " \t"), "|")
+		for _, cell := range cells[1 : len(cells)-1] {
+			widths = append(widths, displayWidth(cell))
+		}
+	}
+
+	line := indentation(s, false) + "|"
+	for i, cell := range row.Cells {
+		text := " " + orgString(cell.Children) + " "
+		if i < len(widths) && widths[i] > displayWidth(text) {
+			text += strings.Repeat(" ", widths[i]-displayWidth(text))
+		}
+		line += text + "|"
+	}
+	return line + "\n"
+}
+
+func tableColumns(t *Table) int {
+	columns := 0
+	for _, row := range t.Rows {
+		if len(row.Cells) > columns {
+			columns = len(row.Cells)
+		}
+	}
+	return columns
+}
+
+// paragraphsText returns the text of the paragraphs in nodes, indenting every
+// line but the first with indent.
+func paragraphsText(nodes []Node, indent string) string {
+	var texts []string
+	for _, n := range nodes {
+		if para, ok := n.(*Paragraph); ok {
+			texts = append(texts, orgString(para.Children))
+		}
+	}
+	text := strings.Join(texts, "\n")
+	return strings.Replace(text, "\n", "\n"+indent, -1) + "\n"
+}
+
+// indentText indents the lines of text that aren't blank with indent.
+func indentText(text, indent string) string {
+	lines := strings.SplitAfter(text, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+func indentLines(text, indent string) string {
+	if text == "" {
+		return indent + "\n"
+	}
+	return indent + strings.Replace(text, "\n", "\n"+indent, -1) + "\n"
+}
+
+// indentation returns the leading whitespace of a node's source, from its tail
+// when fromTail is set.
+func indentation(s *source, fromTail bool) string {
+	if s == nil {
+		return ""
+	}
+	text := s.raw
+	switch {
+	case fromTail:
+		text = s.tail
+	case s.head != "":
+		text = s.head
+	}
+	line := firstLine(text)
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func firstLine(text string) string {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return strings.TrimSuffix(text[:i], "\r")
+	}
+	return text
+}
+
+// blankSuffix returns the blank lines at the end of text.
+func blankSuffix(text string) string {
+	end := len(text)
+	for end > 0 {
+		start := strings.LastIndexByte(text[:end-1], '\n') + 1
+		if strings.TrimSpace(text[start:end]) != "" {
+			break
+		}
+		end = start
+	}
+	return text[end:]
+}
+
+// displayWidth returns the number of columns text takes up, with tabs
+// advancing to the next multiple of eight.
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		if r == '\t' {
+			width += 8 - width%8
+			continue
+		}
+		width++
+	}
+	return width
+}
@@ -0,0 +1,220 @@
+package goorgeous
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func writeString(t *testing.T, doc *Document) string {
+	var out bytes.Buffer
+	if err := Write(&out, doc); err != nil {
+		t.Fatalf("Write returned error: %s", err)
+	}
+	return out.String()
+}
+
+func TestWriteRoundTrip(t *testing.T) {
+	testCases := map[string]string{
+		"empty":              "",
+		"no-final-newline":   "* headline\nsome text",
+		"crlf":               "* headline\r\n\r\nsome text\r\n",
+		"leading-blanks":     "\n\n#+TITLE: title\n",
+		"aligned-tags":       "* TODO [#A] headline\t\t:a:b:\n** second          :c:\n",
+		"headline-blanks":    "* one\n\n\n** two\n\nbody   \n\n\n* three\n",
+		"planning":           "* TODO a\n  SCHEDULED: <2024-01-02 Di 9:30>  DEADLINE: <2024-01-05 Fr>\n\nbody\n",
+		"properties":         "* a\n  :PROPERTIES:\n  :Z:    last\n  :A+:   first\n  :END:\nbody\n",
+		"logbook":            "* a\n  :LOGBOOK:\n  CLOCK: [2024-01-02 Di 10:00]--[2024-01-02 Di 11:30] =>  1:30\n\n  - State \"DONE\" from \"TODO\" [2024-01-02 Di 11:30]\n  :END:\n",
+		"indented-block":     "  #+BEGIN_SRC go\n  fmt.Println()\n  #+END_SRC\n",
+		"quote-blanks":       "#+begin_quote\n\n  quoted *text*\n\n  more\n#+end_quote\n\n",
+		"ragged-table":       "|a|   b |\n|-+--|\n|  c |d|\n",
+		"table-cookies":      "| / | < |\n| <r5> |  |\n| a | b |\n",
+		"affiliated":         "#+caption: a /b/\n#+NAME: n\n#+attr_html: :class c\n#+ATTR_HTML: :id i\n[[file:a.png]]\n\n#+CAPTION: dangling\n\ntext\n",
+		"table-formulas":     "  | 1 | 2 |\n  #+tblfm: $2=$1*2\n  #+TBLFM: @1$1=0\n\ntext\n",
+		"lists":              "  - a\n  - b\n\n1) x\n3. [@3] y\n- term :: def\n",
+		"nested-lists":       "- a\n    1. one\n\t- tab\n- b\n",
+		"checkboxes":         "* tasks [1/2]\n1. [@2] [X] done\n   - [ ] term :: def [%]\n",
+		"multi-line-items":   "- a\n  wrapped\n\n  #+BEGIN_SRC sh\n  ls\n  #+END_SRC\n\n- b\n\n\nafter\n",
+		"fixed-width-drawer": ":PROPERTIES:\n:ID:   1\n:END:\n:  fixed\n:\n",
+		"footnotes":          "text[fn:1]\n\n[fn:1]   the note\n",
+		"odd-markup":         "*not bold\n/a/b/ and [[link]]\n-----------\n#  comment\n#+KEY:value\n",
+	}
+
+	for caseName, in := range testCases {
+		doc, _ := Parse([]byte(in))
+		if got := writeString(t, doc); got != in {
+			t.Errorf("case %s: Write(Parse(%q)) = %q\nwants: %q", caseName, in, got, in)
+		}
+	}
+}
+
+func TestWriteRoundTripFile(t *testing.T) {
+	in, err := ioutil.ReadFile("./testdata/test.org")
+	if err != nil {
+		t.Fatalf("couldn't read test.org: %s", err)
+	}
+	doc, _ := Parse(in)
+	if got := writeString(t, doc); got != string(in) {
+		t.Errorf("Write(Parse(test.org)) doesn't match test.org:\n%s", got)
+	}
+}
+
+func TestWriteEdited(t *testing.T) {
+	testCases := map[string]struct {
+		in       string
+		edit     func(doc *Document)
+		expected string
+	}{
+		"keyword-keeps-tag-column": {
+			"* TODO headline         :tag:\nbody\n",
+			func(doc *Document) { doc.Children[0].(*Headline).Keyword = "DONE" },
+			"* DONE headline         :tag:\nbody\n",
+		},
+		"longer-title": {
+			"* headline  :tag:\n\n** child\n",
+			func(doc *Document) {
+				doc.Children[0].(*Headline).Title = []Node{&Text{Value: "a much longer headline"}}
+			},
+			"* a much longer headline :tag:\n\n** child\n",
+		},
+		"table-cell": {
+			"| a     | b |\n|-------+---|\n| 1     | 2 |\n\ntext\n",
+			func(doc *Document) {
+				table := doc.Children[0].(*Section).Children[0].(*Table)
+				table.Rows[2].Cells[0].Children = []Node{&Text{Value: "3"}}
+			},
+			"| a     | b |\n|-------+---|\n| 3     | 2 |\n\ntext\n",
+		},
+		"caption": {
+			"#+name: t\n#+caption: old\n| a |\n| b |\n",
+			func(doc *Document) {
+				table := doc.Children[0].(*Section).Children[0].(*Table)
+				table.Affiliated.Caption = []Node{&Text{Value: "new"}}
+			},
+			"#+NAME: t\n#+CAPTION: new\n| a |\n| b |\n",
+		},
+		"affiliated-element": {
+			"#+ATTR_HTML: :class x\n#+BEGIN_QUOTE\nq\n#+END_QUOTE\n",
+			func(doc *Document) {
+				doc.Children[0].(*Section).Children[0].(*Block).Name = "CENTER"
+			},
+			"#+ATTR_HTML: :class x\n#+BEGIN_CENTER\nq\n#+END_CENTER\n",
+		},
+		"block-contents": {
+			"  #+BEGIN_SRC sh\n  echo\n  #+END_SRC\n\n\nafter\n",
+			func(doc *Document) {
+				doc.Children[0].(*Section).Children[0].(*Block).Lines = []string{"  ls"}
+			},
+			"  #+BEGIN_SRC sh\n  ls\n  #+END_SRC\n\n\nafter\n",
+		},
+		"new-sublist": {
+			"- a\n   + b\n- c\n",
+			func(doc *Document) {
+				list := doc.Children[0].(*Section).Children[0].(*List)
+				sublist := list.Items[0].Children[1].(*List)
+				sublist.Items = append(sublist.Items, &ListItem{Bullet: "+", Children: []Node{
+					&Paragraph{Children: []Node{&Text{Value: "new"}}},
+					&List{Kind: OrderedList, Items: []*ListItem{{Bullet: "1.", Children: []Node{&Paragraph{Children: []Node{&Text{Value: "deep"}}}}}}},
+				}})
+			},
+			"- a\n   + b\n   + new\n     1. deep\n- c\n",
+		},
+		"item-contents": {
+			"- a\n  wrapped\n\n  | 1 | 2 |\n- b\n",
+			func(doc *Document) {
+				item := doc.Children[0].(*Section).Children[0].(*List).Items[0]
+				item.Children[1].(*Table).Rows[0].Cells[0].Children = []Node{&Text{Value: "3"}}
+				item.Children = append(item.Children, &Paragraph{Children: []Node{&Text{Value: "new\nparagraph"}}})
+			},
+			"- a\n  wrapped\n\n  | 3 | 2 |\n\n  new\n  paragraph\n- b\n",
+		},
+		"checkbox": {
+			"- [ ] task\n- other\n",
+			func(doc *Document) {
+				list := doc.Children[0].(*Section).Children[0].(*List)
+				list.Items[0].Checkbox = "X"
+				list.Items[1].Checkbox = " "
+			},
+			"- [X] task\n- [ ] other\n",
+		},
+		"new-paragraph": {
+			"* headline\nfirst\n",
+			func(doc *Document) {
+				section := doc.Children[0].(*Headline).Children[0].(*Section)
+				section.Children = append(section.Children, &Paragraph{Children: []Node{&Text{Value: "second"}}})
+			},
+			"* headline\nfirst\n\nsecond\n",
+		},
+		"planning": {
+			"* TODO task :x:\n  DEADLINE: <2024-01-05 Fri>\nbody\n",
+			func(doc *Document) {
+				h := doc.Children[0].(*Headline)
+				h.Keyword, h.Done = "DONE", true
+				h.Planning.Closed = &Timestamp{Time: time.Date(2024, 1, 4, 16, 5, 0, 0, time.UTC), HasTime: true}
+			},
+			"* DONE task :x:\n  CLOSED: [2024-01-04 Thu 16:05] DEADLINE: <2024-01-05 Fri>\nbody\n",
+		},
+		"new-planning": {
+			"* task\nbody\n",
+			func(doc *Document) {
+				doc.Children[0].(*Headline).Planning = &Planning{Scheduled: &Timestamp{Active: true, Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}}
+			},
+			"* task\nSCHEDULED: <2024-01-02 Tue>\nbody\n",
+		},
+		"keyword-keeps-drawers": {
+			"* TODO a\n  DEADLINE: <2024-01-05 Fr>\n  :PROPERTIES:\n  :Z:    last\n  :A:    first\n  :END:\n",
+			func(doc *Document) { doc.Children[0].(*Headline).Keyword = "DONE" },
+			"* DONE a\n  DEADLINE: <2024-01-05 Fr>\n  :PROPERTIES:\n  :Z:    last\n  :A:    first\n  :END:\n",
+		},
+		"properties": {
+			"* a\n:PROPERTIES:\n:Z: last\n:END:\nbody\n",
+			func(doc *Document) { doc.Children[0].(*Headline).Properties["EFFORT"] = "2:00" },
+			"* a\n:PROPERTIES:\n:Z: last\n:EFFORT: 2:00\n:END:\nbody\n",
+		},
+		"properties-keep-lines": {
+			"* a\n  :PROPERTIES:\n  :ID:  abc\n  :Z:    last\n  :A:   x\n  :A+:  y\n  :B:   gone\n  :END:\nbody\n",
+			func(doc *Document) {
+				h := doc.Children[0].(*Headline)
+				h.Properties["Z"] = "new"
+				h.Properties["A"] = "z"
+				h.Properties["EFFORT"] = "2:00"
+				delete(h.Properties, "B")
+			},
+			"* a\n  :PROPERTIES:\n  :ID:  abc\n  :Z: new\n  :A: z\n  :EFFORT: 2:00\n  :END:\nbody\n",
+		},
+		"clock": {
+			"* a\n  :LOGBOOK:\n  CLOCK: [2024-01-02 Tue 10:00]\n  :END:\n",
+			func(doc *Document) {
+				drawer := doc.Children[0].(*Headline).Children[0].(*Section).Children[0].(*Drawer)
+				clock := drawer.Children[0].(*Clock)
+				clock.Timestamp.End = time.Date(2024, 1, 2, 10, 45, 0, 0, time.UTC)
+				clock.Duration = 45 * time.Minute
+			},
+			"* a\n  :LOGBOOK:\n  CLOCK: [2024-01-02 Tue 10:00]--[2024-01-02 Tue 10:45] =>  0:45\n  :END:\n",
+		},
+		"timestamp": {
+			"meet <2024-01-02 Di 9:30 +1w> and [2024-01-05]--[2024-01-06]\n",
+			func(doc *Document) {
+				para := doc.Children[0].(*Section).Children[0].(*Paragraph)
+				para.Children[1].(*Timestamp).Delay = &Delay{Value: 2, Unit: "d"}
+			},
+			"meet <2024-01-02 Tue 09:30 +1w -2d> and [2024-01-05 Fri]--[2024-01-06 Sat]\n",
+		},
+		"new-headline": {
+			"* one\ntext",
+			func(doc *Document) {
+				doc.Children = append(doc.Children, &Headline{Level: 1, Title: []Node{&Text{Value: "two"}}, Tags: []string{"x"}})
+			},
+			"* one\ntext\n* two :x:\n",
+		},
+	}
+
+	for caseName, tc := range testCases {
+		doc, _ := Parse([]byte(tc.in))
+		tc.edit(doc)
+		if got := writeString(t, doc); got != tc.expected {
+			t.Errorf("case %s: Write() = %q\nwants: %q", caseName, got, tc.expected)
+		}
+	}
+}